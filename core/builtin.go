@@ -11,29 +11,69 @@ var (
 	False = List{}
 )
 
-// BuiltinScope returns the default environment for all evaluations that is always present.
+// builtins lists all built-in functions grouped by the capability they require.
 // It contains the 7 basic operators from "The Roots of LISP" + `lambda` + `defun`
-func BuiltinScope() Scope {
-	fns := map[string]SExpr{
-		"quote": Fn{name: "quote", fn: quote},
-		"atom":  Fn{name: "atom", fn: atom},
-		"eq":    Fn{name: "eq", fn: eq},
-		"car":   Fn{name: "car", fn: car},
-		"cdr":   Fn{name: "cdr", fn: cdr},
-		"cons":  Fn{name: "cons", fn: cons},
-		"cond":  Fn{name: "cond", fn: cond},
+var builtins = map[Capability][]Fn{
+	Pure: {
+		{name: "quote", fn: quote,
+			doc: "(quote x) returns x unevaluated, 'x is a shorthand"},
+		{name: "atom", fn: atom,
+			doc: "(atom x) returns t if the value of x is an atom or (), () otherwise"},
+		{name: "eq", fn: eq,
+			doc: "(eq x y) returns t if the values of x and y are the same atom or both (), () otherwise"},
+		{name: "car", fn: car,
+			doc: "(car x) returns the first element of the list x"},
+		{name: "cdr", fn: cdr,
+			doc: "(cdr x) returns the list x without its first element"},
+		{name: "cons", fn: cons,
+			doc: "(cons x y) returns the list y with x prepended"},
+		{name: "cond", fn: cond,
+			doc: "(cond (p1 e1) ... (pn en)) returns the value of the first e whose p is t"},
 		// lambda and defun are placed here for convenience
-		"lambda": Fn{name: "lambda", fn: lambda},
-		"label":  Fn{name: "label", fn: label},
-		"defun":  Fn{name: "defun", fn: defun},
+		{name: "lambda", fn: lambda,
+			doc: "(lambda (p1 ... pn) e) returns a function evaluating e with its arguments bound to p1 ... pn"},
+		{name: "label", fn: label,
+			doc: "(label f (lambda ...)) names the function f so that it can call itself"},
+		{name: "defun", fn: defun,
+			doc: "(defun f (p1 ... pn) e) defines the function f, a shorthand for (label f (lambda (p1 ... pn) e))"},
+		{name: "defpackage", fn: defpackage,
+			doc: "(defpackage p (f ...)) defines the package p exporting the names f ..., see in-package"},
+		{name: "in-package", fn: inPackage,
+			doc: "(in-package p) makes the following definitions bind p:name, (in-package user) switches back"},
+	},
+	Printing: {
 		// print - for a rudimentary REPL
-		"print": Fn{name: "print", fn: print},
-	}
+		{name: "print", fn: print,
+			doc: "(print x ...) writes the values of the arguments to the current output port"},
+		{name: "write", fn: write,
+			doc: "(write x [port]) writes the value of x so that it can be read back"},
+		{name: "display", fn: display,
+			doc: "(display x [port]) writes the value of x for humans, strings without quotes"},
+		{name: "newline", fn: newline,
+			doc: "(newline [port]) writes a line break"},
+		{name: "read-line", fn: readLine,
+			doc: "(read-line [port]) reads a line as a string, () at the end of input"},
+		{name: "with-output-to-string", fn: withOutputToString,
+			doc: "(with-output-to-string e ...) returns what evaluating the expressions writes to the current output port"},
+		{name: "current-input-port", fn: currentInputPort,
+			doc: "(current-input-port) returns the port read-line reads from by default"},
+		{name: "current-output-port", fn: currentOutputPort,
+			doc: "(current-output-port) returns the port output goes to by default"},
+		{name: "current-error-port", fn: currentErrorPort,
+			doc: "(current-error-port) returns the port for error messages"},
+	},
+	Reflection: {
+		{name: "trace", fn: trace,
+			doc: "(trace f ...) prints the calls of the functions f ... and their results, (trace) lists traced functions"},
+		{name: "untrace", fn: untrace,
+			doc: "(untrace f ...) stops tracing the functions f ..., (untrace) stops tracing all of them"},
+	},
+}
 
-	return Scope{
-		parent: nil, // this is supposed to be the root scope
-		vals:   fns, // no built-in values defined so far
-	}
+// BuiltinScope returns the default environment for all evaluations that is always present.
+// It is granted all capabilities, use NewScope to build a restricted one.
+func BuiltinScope() Scope {
+	return NewScope(AllCapabilities...)
 }
 
 // The following 7 operators are the "Maxwell equations of programming" as Paul Graham called them.
@@ -42,8 +82,11 @@ func BuiltinScope() Scope {
 // quote returns it's parameter unchanged. (quote x) returns x.
 // Exists mostly to prevent list evaluation (which is their default behaviour)
 func quote(scope Scope, args ...SExpr) (SExpr, error) {
-	if len(args) != 1 {
-		return nil, errors.New(fmt.Sprintf("quote: expects 1 argument, %d given", len(args)))
+	if err := checkArity("quote", args, 1, 1); err != nil {
+		return nil, err
+	}
+	if err := scope.checkSize(args[0]); err != nil {
+		return nil, fmt.Errorf("quote: %w", err)
 	}
 
 	return args[0], nil
@@ -52,15 +95,15 @@ func quote(scope Scope, args ...SExpr) (SExpr, error) {
 // atom returns the atom t if the value of x is an atom or the empty
 // list. Otherwise it returns ().
 func atom(scope Scope, args ...SExpr) (SExpr, error) {
-	if len(args) != 1 {
-		return nil, errors.New(fmt.Sprintf("atom: expects 1 argument, %d given", len(args)))
+	if err := checkArity("atom", args, 1, 1); err != nil {
+		return nil, err
 	}
 	val, err := args[0].Eval(scope)
 	if err != nil {
 		return nil, fmt.Errorf("atom: evaluation error: %w", err)
 	}
 	switch v := val.(type) {
-	case Symbol:
+	case Symbol, String:
 		return True, nil
 	case List:
 		if v.IsEmpty() {
@@ -74,8 +117,8 @@ func atom(scope Scope, args ...SExpr) (SExpr, error) {
 // eq returns t if the values of x and y are the same atom or both the
 // empty list, and () otherwise
 func eq(scope Scope, args ...SExpr) (SExpr, error) {
-	if len(args) != 2 {
-		return nil, errors.New(fmt.Sprintf("eq: expects 2 arguments, got %d", len(args)))
+	if err := checkArity("eq", args, 2, 2); err != nil {
+		return nil, err
 	}
 
 	// evaluate arguments
@@ -89,12 +132,16 @@ func eq(scope Scope, args ...SExpr) (SExpr, error) {
 	}
 
 	// if equal atoms return t
-	// (the only atoms present now are symbols)
 	a1, ok1 := arg1.(Symbol)
 	a2, ok2 := arg2.(Symbol)
 	if ok1 && ok2 && a1.name == a2.name {
 		return True, nil
 	}
+	s1, ok1 := arg1.(String)
+	s2, ok2 := arg2.(String)
+	if ok1 && ok2 && s1.val == s2.val {
+		return True, nil
+	}
 
 	// if both are empty lists return t
 	l1, ok1 := arg1.(List)
@@ -109,8 +156,8 @@ func eq(scope Scope, args ...SExpr) (SExpr, error) {
 
 // car expects it's only argument to be a list, and returns its first element
 func car(scope Scope, args ...SExpr) (SExpr, error) {
-	if len(args) != 1 {
-		return nil, errors.New(fmt.Sprintf("car: expects 1 argument, got %d", len(args)))
+	if err := checkArity("car", args, 1, 1); err != nil {
+		return nil, err
 	}
 	// evaluate argument
 	arg, err := args[0].Eval(scope)
@@ -119,7 +166,7 @@ func car(scope Scope, args ...SExpr) (SExpr, error) {
 	}
 	l, ok := arg.(List)
 	if !ok {
-		return nil, errors.New(fmt.Sprintf("car: argument must be a list, got %v", args[0]))
+		return nil, TypeError{Fn: "car", Expected: "a list", Got: arg}
 	}
 	if l.First() == nil {
 		return List{}, nil
@@ -130,8 +177,8 @@ func car(scope Scope, args ...SExpr) (SExpr, error) {
 
 // cdr expects its only argument to be a list, and returns everything after the first element (may be an empty list).
 func cdr(scope Scope, args ...SExpr) (SExpr, error) {
-	if len(args) != 1 {
-		return nil, errors.New(fmt.Sprintf("cdr: expects 1 argument, got %d", len(args)))
+	if err := checkArity("cdr", args, 1, 1); err != nil {
+		return nil, err
 	}
 	// evaluate argument
 	arg, err := args[0].Eval(scope)
@@ -140,7 +187,7 @@ func cdr(scope Scope, args ...SExpr) (SExpr, error) {
 	}
 	l, ok := arg.(List)
 	if !ok {
-		return nil, errors.New(fmt.Sprintf("cdr: argument must be a list, got %v", args[0]))
+		return nil, TypeError{Fn: "cdr", Expected: "a list", Got: arg}
 	}
 
 	second := l.Second()
@@ -156,8 +203,8 @@ func cdr(scope Scope, args ...SExpr) (SExpr, error) {
 // (cons x y) expects the value of y to be a list, and returns a list
 // containing the value of x followed by the elements of the value of y
 func cons(scope Scope, args ...SExpr) (SExpr, error) {
-	if len(args) != 2 {
-		return nil, errors.New(fmt.Sprintf("cons: expects 2 arguments, got %d", len(args)))
+	if err := checkArity("cons", args, 2, 2); err != nil {
+		return nil, err
 	}
 	// evaluate arguments
 	arg1, err := args[0].Eval(scope)
@@ -170,13 +217,21 @@ func cons(scope Scope, args ...SExpr) (SExpr, error) {
 	}
 	rest, ok := arg2.(List)
 	if !ok {
-		return nil, errors.New(fmt.Sprintf("cons: 2nd argument must be a list, got %v", arg2))
+		return nil, TypeError{Fn: "cons", Arg: 2, Expected: "a list", Got: arg2}
 	}
 
-	return List{
+	if err := scope.allocCons(); err != nil {
+		return nil, fmt.Errorf("cons: %w", err)
+	}
+	l := List{
 		first:  arg1,
 		second: rest,
-	}, nil
+	}
+	if err := scope.checkSize(l); err != nil {
+		return nil, fmt.Errorf("cons: %w", err)
+	}
+
+	return l, nil
 }
 
 // cond performs conditional evaluation.
@@ -189,7 +244,7 @@ func cond(scope Scope, args ...SExpr) (SExpr, error) {
 	for i, arg := range args {
 		p, ok := arg.(List)
 		if !ok {
-			return nil, errors.New(fmt.Sprintf("cond: argument #%d is not a list, it's %v", i+1, args[i]))
+			return nil, TypeError{Fn: "cond", Arg: i + 1, Expected: "a list", Got: arg}
 		}
 
 		items := p.Flatten()
@@ -224,53 +279,49 @@ func cond(scope Scope, args ...SExpr) (SExpr, error) {
 // lambda creates an anonymous function and returns it
 // example: (lambda (a b) (cons a b))
 func lambda(scope Scope, args ...SExpr) (SExpr, error) {
-	if len(args) < 1 {
-		return nil, errors.New("lambda: expects at least 1 argument")
+	if err := checkArity("lambda", args, 1, 2); err != nil {
+		return nil, err
 	}
 	paramList, ok := args[0].(List)
 	if !ok {
-		return nil, errors.New("lambda: first parameter is not a list")
+		return nil, TypeError{Fn: "lambda", Arg: 1, Expected: "a parameter list", Got: args[0]}
 	}
 
 	// get function body
 	if len(args) < 2 {
 		// if function body is empty, lambda will return an empty list
 		return Fn{
-			srcName: paramList.srcName,
-			line:    paramList.line,
-			pos:     paramList.pos,
+			span:     paramList.span,
+			evalArgs: true,
 			fn: func(scope Scope, args ...SExpr) (SExpr, error) {
 				return List{}, nil
 			},
 		}, nil
 	}
 	body := args[1]
+	pkg := scope.Package()
 
 	params := []Symbol{}
 	for p := range paramList.Items() {
 		p, ok := p.(Symbol)
 		if !ok {
-			return nil, errors.New(fmt.Sprintf("lambda: parameter #%d in parameter list is not a symbol", len(params)+1))
+			return nil, TypeError{Fn: "lambda", Arg: 1, Expected: "a list of symbols", Got: paramList}
 		}
 		params = append(params, p)
 	}
 
 	return Fn{
-		srcName: paramList.srcName,
-		line:    paramList.line,
-		pos:     paramList.pos,
+		span:     paramList.span,
+		evalArgs: true,
 		fn: func(scope Scope, args ...SExpr) (SExpr, error) {
 			if len(params) != len(args) {
-				return nil, errors.New(fmt.Sprintf("lambda: arity error: expected %d parameters, got %d", len(params), len(args)))
+				return nil, ArityError{Fn: "lambda", Min: len(params), Max: len(params), Got: len(args)}
 			}
 
-			// evaluate operands and bind them to parameter symbols
+			// bind operand values to parameter symbols
 			scope = scope.NewLayer()
-			for i, a := range args {
-				v, err := a.Eval(scope)
-				if err != nil {
-					return nil, fmt.Errorf("lambda: error evaluating parameter #%d=%v: %w", i+1, a, err)
-				}
+			scope.pkg = pkg
+			for i, v := range args {
 				scope.Bind(params[i].name, v)
 			}
 
@@ -282,12 +333,12 @@ func lambda(scope Scope, args ...SExpr) (SExpr, error) {
 
 // label creates a named function in scope and returns it
 func label(scope Scope, args ...SExpr) (SExpr, error) {
-	if len(args) != 2 {
-		return nil, errors.New("label: expects 2 arguments (function name and a lambda expression)")
+	if err := checkArity("label", args, 2, 2); err != nil {
+		return nil, err
 	}
 	fnSym, ok := args[0].(Symbol)
 	if !ok {
-		return nil, errors.New(fmt.Sprintf("label: 1st parameter (function name) is not a symbol but %v", args[0]))
+		return nil, TypeError{Fn: "label", Arg: 1, Expected: "a function name", Got: args[0]}
 	}
 
 	// args[1] is expected to be a lambda (but could be another function)
@@ -297,37 +348,28 @@ func label(scope Scope, args ...SExpr) (SExpr, error) {
 	}
 	fn, ok := fnVal.(Fn)
 	if !ok {
-		return nil, errors.New(fmt.Sprintf("label: second parameter is not a function but %v", fnVal))
+		return nil, TypeError{Fn: "label", Arg: 2, Expected: "a function", Got: fnVal}
 	}
 	// TODO: is it possible to make it nicer than this patching?
-	fn.name = fnSym.name
-	fn.srcName = fnSym.srcName
-	fn.line = fnSym.line
-	fn.pos = fnSym.pos
+	fn.name = scope.qualify(fnSym.name)
+	fn.span = fnSym.span
 
-	scope.Bind(fnSym.name, fn)
+	scope.Bind(fn.name, fn)
 
 	return fn, nil
 }
 
 // defun is a syntactic sugar for `label`
 func defun(scope Scope, args ...SExpr) (SExpr, error) {
-	if len(args) != 3 {
-		return nil, errors.New("defun: expects 3 arguments (function name, parameter list, function body)")
+	if err := checkArity("defun", args, 3, 3); err != nil {
+		return nil, err
 	}
 
 	// Carry the function name's source location into the synthetic lambda list
 	// so that errors inside the function body trace back to the defun call site.
-	var srcName string
-	var line, pos uint
-	if sym, ok := args[0].(Symbol); ok {
-		srcName, line, pos = sym.srcName, sym.line, sym.pos
-	}
-
-	fn, err := label(scope, args[0], NewList(
-		srcName, line, pos,
-		Symbol{name: "lambda"},
-		args[1], args[2],
+	fn, err := label(scope, args[0], newList(
+		args[0].Span(),
+		[]SExpr{Symbol{name: "lambda"}, args[1], args[2]},
 	))
 	// label binds function to the name for us
 	if err != nil {
@@ -342,11 +384,14 @@ func print(scope Scope, args ...SExpr) (SExpr, error) {
 	for i, a := range args {
 		aVal, err := a.Eval(scope)
 		if err != nil {
-			return nil, fmt.Errorf("eval: argument #%d: %w", i, err)
+			return nil, fmt.Errorf("print: argument #%d evaluation error: %w", i+1, err)
 		}
 		argsValStrs = append(argsValStrs, aVal.String())
 	}
 
-	fmt.Println(strings.Join(argsValStrs, " "))
-	return nil, nil
+	line := strings.Join(argsValStrs, " ") + "\n"
+	if err := scope.writeTo(scope.outputPort(), line); err != nil {
+		return nil, fmt.Errorf("print: %w", err)
+	}
+	return Void, nil
 }
@@ -0,0 +1,109 @@
+package core
+
+import "fmt"
+
+// Capability names a set of builtins that can be granted to a scope.
+// Hosted scripts can only use the builtins of capabilities the host granted.
+type Capability string
+
+const (
+	// Pure covers the list operators: the 7 axioms, lambda, label and defun
+	Pure Capability = "pure"
+	// Printing covers console I/O through the current ports
+	Printing Capability = "printing"
+	// FileIO covers reading and writing files
+	FileIO Capability = "file-io"
+	// Process covers access to the host process (arguments, environment, exit)
+	Process Capability = "process"
+	// Concurrency covers running code in parallel
+	Concurrency Capability = "concurrency"
+	// Reflection covers inspecting and instrumenting the interpreter itself
+	Reflection Capability = "reflection"
+)
+
+// AllCapabilities lists every known capability
+var AllCapabilities = []Capability{Pure, Printing, FileIO, Process, Concurrency, Reflection}
+
+// CapabilityError is returned when code uses a builtin its scope was not granted
+type CapabilityError struct {
+	// Name is the name of the missing builtin
+	Name string
+	// Capability is the capability required to use it,
+	// it is empty if the builtin was denied individually
+	Capability Capability
+	Pos        Position
+}
+
+func (e CapabilityError) Error() string {
+	loc := e.Pos.String()
+	if e.Capability == "" {
+		return fmt.Sprintf("%s: %s is denied", loc, e.Name)
+	}
+	return fmt.Sprintf("%s: %s requires capability %s", loc, e.Name, e.Capability)
+}
+
+// NewScope returns a root scope containing only the builtins of the given capabilities.
+// Builtins of other capabilities are unbound but remembered so that
+// using them reports which capability is required.
+// A single builtin can be replaced by binding another value to its name with Bind.
+func NewScope(caps ...Capability) Scope {
+	vals := map[string]SExpr{}
+	denied := map[string]Capability{}
+	for c, fns := range builtins {
+		for _, fn := range fns {
+			denied[fn.name] = c
+		}
+	}
+	for _, c := range caps {
+		for _, fn := range builtins[c] {
+			vals[fn.name] = fn
+			delete(denied, fn.name)
+		}
+	}
+
+	return Scope{
+		parent:  nil, // this is supposed to be the root scope
+		vals:    vals,
+		session: &session{denied: denied, tracing: newTracing(), packages: newPackages()},
+	}
+}
+
+// Deny removes individual builtins from the scope. Called on a layer
+// it removes them from the root scope, so they are denied to everything
+// sharing it, definitions of the same names in layers are kept.
+func (scope Scope) Deny(names ...string) {
+	scope.Withhold("", names...)
+}
+
+// Withhold removes builtins requiring capability c from the scope like Deny,
+// using them reports that c is required. Hosts use it for the builtins
+// they define themselves when c is not granted.
+func (scope Scope) Withhold(c Capability, names ...string) {
+	root := &scope
+	for root.parent != nil {
+		root = root.parent
+	}
+	for _, name := range names {
+		delete(root.vals, name)
+		if scope.session != nil {
+			scope.session.denied[name] = c
+		}
+	}
+}
+
+// missingCapability returns an error if sym names a builtin withheld from the scope
+func (scope Scope) missingCapability(sym Symbol) error {
+	if scope.session == nil {
+		return nil
+	}
+	c, ok := scope.session.denied[sym.name]
+	if !ok {
+		return nil
+	}
+
+	return CapabilityError{
+		Name:       sym.name,
+		Capability: c,
+		Pos:        sym.Pos(),
+	}
+}
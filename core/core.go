@@ -10,6 +10,11 @@
 // The eval.lisp file is a copy from https://paulgraham.com/rootsoflisp.html.
 package core
 
+import (
+	"maps"
+	"slices"
+)
+
 // SExpr represents a S-expression (atom or a list).
 // Go doesn't have union types (well, it does with generics
 // but they are still useless since you can't have a slice of them)
@@ -19,6 +24,67 @@ type SExpr interface {
 	Eval(scope Scope) (SExpr, error)
 	// String returns a textual representation of a value (P in REPL)
 	String() string
+	// Pos returns where the expression was read from.
+	// Values constructed at runtime have a zero Position.
+	Pos() Position
+	// Span returns the part of the source the expression was read from
+	Span() Span
+}
+
+// Position is a location in source code
+type Position struct {
+	SrcName string
+	// Line starts from 1
+	Line uint
+	// Col is counted in runes starting from 1
+	Col uint
+	// Offset is counted in bytes from the start of the input
+	Offset uint
+}
+
+func (p Position) String() string {
+	return location(p.SrcName, p.Line, p.Col)
+}
+
+// Span is a range of source code, End points right after its last character
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// IsAtom reports whether e is an atom or the empty list, like the `atom` builtin
+func IsAtom(e SExpr) bool {
+	switch v := e.(type) {
+	case Symbol, String:
+		return true
+	case List:
+		return v.IsEmpty()
+	}
+	return false
+}
+
+// IsSymbol reports whether e is a symbol
+func IsSymbol(e SExpr) bool {
+	_, ok := e.(Symbol)
+	return ok
+}
+
+// IsString reports whether e is a string
+func IsString(e SExpr) bool {
+	_, ok := e.(String)
+	return ok
+}
+
+// IsList reports whether e is a list (including the empty one)
+func IsList(e SExpr) bool {
+	_, ok := e.(List)
+	return ok
+}
+
+// IsFn reports whether e is a function
+func IsFn(e SExpr) bool {
+	_, ok := e.(Fn)
+	return ok
 }
 
 // Scope stores values (functions are values) bound to names(aka symbols).
@@ -26,19 +92,71 @@ type SExpr interface {
 // and one symbol can only be either a function or a value.
 // Shadowing is allowed and you can rebind a function symbol to a value and vice versa.
 type Scope struct {
-	parent *Scope // to enable lexical scope, shadowing and immutability
-	vals   map[string]SExpr
+	parent  *Scope // to enable lexical scope, shadowing and immutability
+	vals    map[string]SExpr
+	session *session // shared by all layers
+	// pkg is the package of the function being evaluated,
+	// empty at the top level where the current package is used
+	pkg string
+}
+
+// session holds the state shared by all layers of a Scope.
+type session struct {
+	limits Limits
+	usage  usage
+	ports  ports
+	// denied maps names of withheld builtins to the capability they require
+	denied   map[string]Capability
+	observer Observer
+	depth    int // number of observed calls in progress
+	// tracing and packages are shared by all copies of the session
+	tracing  *tracing
+	packages *packages
 }
 
 func (scope Scope) NewLayer() Scope {
 	return Scope{
-		parent: &scope,
-		vals:   map[string]SExpr{},
+		parent:  &scope,
+		vals:    map[string]SExpr{},
+		session: scope.session,
+		pkg:     scope.pkg,
+	}
+}
+
+// WithSessionOf returns the scope sharing the limits, ports, capabilities
+// and observer of other, e.g. to run a function in the scope it was defined in
+// on behalf of code evaluated in other
+func (scope Scope) WithSessionOf(other Scope) Scope {
+	scope.session = other.session
+	return scope
+}
+
+// Commit binds the values bound in the top layer of staged in scope.
+// staged is a layer made with NewLayer on top of scope to evaluate code tentatively,
+// committing it keeps the definitions once all the code succeeded.
+func (scope Scope) Commit(staged Scope) {
+	for _, name := range slices.Sorted(maps.Keys(staged.vals)) {
+		scope.Bind(name, staged.vals[name])
 	}
 }
 
 func (scope Scope) Bind(s string, v SExpr) {
 	scope.vals[s] = v
+	if scope.session != nil {
+		// binding a denied builtin in the root scope replaces it,
+		// binding it in a layer (e.g. as a parameter) only shadows it
+		if scope.parent == nil {
+			delete(scope.session.denied, s)
+		}
+		if scope.session.observer != nil {
+			scope.session.observer.Bind(s, v)
+		}
+	}
+}
+
+// Unbind removes the binding of name from the top layer of the scope
+func (scope Scope) Unbind(name string) {
+	delete(scope.vals, name)
 }
 
 func (scope Scope) SymbolValue(sym string) (SExpr, bool) {
@@ -50,3 +168,21 @@ func (scope Scope) SymbolValue(sym string) (SExpr, bool) {
 
 	return nil, false
 }
+
+// Names returns the names bound in the scope and all its parents, sorted
+func (scope Scope) Names() []string {
+	seen := map[string]bool{}
+	for scope := &scope; scope != nil; scope = scope.parent {
+		for name := range scope.vals {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Frame is a function call in progress when an error happened
+type Frame struct {
+	// Fn is the name of the called function,
+	// empty for anonymous functions or when the function itself couldn't be evaluated
+	Fn string
+	// Form is the list which was evaluated
+	Form List
+	Pos  Position
+}
+
+func (f Frame) String() string {
+	name := f.Fn
+	if name == "" {
+		name = "?"
+	}
+	return name + " @ " + f.Pos.String()
+}
+
+// EvalError is an evaluation error with the backtrace of function calls that led to it.
+// Use errors.As to get it and errors.Unwrap (or errors.As again) to get the cause.
+//
+// Error returns a single line, Backtrace a compact list of frames
+// and formatting with %+v prints everything including the call forms.
+type EvalError struct {
+	// Err is the original error
+	Err error
+	// Frames is the backtrace, the innermost call first
+	Frames []Frame
+}
+
+// maxBacktrace is the number of frames printed by Backtrace
+const maxBacktrace = 10
+
+func (e *EvalError) Error() string {
+	var b strings.Builder
+
+	if len(e.Frames) > 0 {
+		b.WriteString(e.Frames[0].Pos.String())
+		b.WriteString(": ")
+	}
+	b.WriteString("evaluation error")
+	if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+
+	return b.String()
+}
+
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
+
+// Backtrace returns the innermost frames, one per line
+func (e *EvalError) Backtrace() string {
+	var b strings.Builder
+	for i, f := range e.Frames {
+		if i == maxBacktrace {
+			fmt.Fprintf(&b, "  ... %d more\n", len(e.Frames)-maxBacktrace)
+			break
+		}
+		fmt.Fprintf(&b, "  in %s\n", f)
+	}
+
+	return b.String()
+}
+
+// Format implements fmt.Formatter, %+v prints all frames with their call forms
+func (e *EvalError) Format(s fmt.State, verb rune) {
+	if verb != 'v' || !s.Flag('+') {
+		fmt.Fprint(s, e.Error())
+		return
+	}
+
+	fmt.Fprintln(s, e.Error())
+	for _, f := range e.Frames {
+		fmt.Fprintf(s, "  in %s\n    %s\n", f, f.Form)
+	}
+}
+
+// LoadError is returned when loading a file transactionally fails,
+// none of the definitions made by the file are kept then
+type LoadError struct {
+	SrcName string
+	// Errs are the failures in the order of the forms causing them
+	Errs []error
+}
+
+func (e *LoadError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: loading failed, no definitions were kept:", e.SrcName)
+	for _, err := range e.Errs {
+		b.WriteString("\n")
+		b.WriteString(err.Error())
+	}
+
+	return b.String()
+}
+
+func (e *LoadError) Unwrap() []error {
+	return e.Errs
+}
+
+// UnboundSymbolError is returned when evaluating a symbol which has no value
+type UnboundSymbolError struct {
+	Name string
+	Pos  Position
+}
+
+func (e UnboundSymbolError) Error() string {
+	return fmt.Sprintf("%s: unbound symbol %s", e.Pos, e.Name)
+}
+
+// ArityError is returned when a function is called with a wrong number of arguments
+type ArityError struct {
+	Fn string
+	// Min and Max are the accepted numbers of arguments, Max is -1 if there is no upper bound
+	Min, Max int
+	Got      int
+}
+
+func (e ArityError) Error() string {
+	var expected string
+	switch {
+	case e.Max < 0:
+		expected = "at least " + arguments(e.Min)
+	case e.Min == e.Max:
+		expected = arguments(e.Min)
+	case e.Min+1 == e.Max:
+		expected = fmt.Sprintf("%d or %s", e.Min, arguments(e.Max))
+	default:
+		expected = fmt.Sprintf("%d to %s", e.Min, arguments(e.Max))
+	}
+
+	return fmt.Sprintf("%s: expects %s, got %d", e.Fn, expected, e.Got)
+}
+
+func arguments(n int) string {
+	if n == 1 {
+		return "1 argument"
+	}
+	return fmt.Sprintf("%d arguments", n)
+}
+
+// checkArity returns an ArityError if the number of args is not between min and max,
+// max < 0 means there is no upper bound
+func checkArity(fn string, args []SExpr, min, max int) error {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		return ArityError{Fn: fn, Min: min, Max: max, Got: len(args)}
+	}
+	return nil
+}
+
+// TypeError is returned when a function gets an argument of a wrong type
+type TypeError struct {
+	Fn string
+	// Arg is the number of the argument starting from 1,
+	// it is 0 for functions of a single argument
+	Arg int
+	// Expected describes the expected type, e.g. "a list"
+	Expected string
+	Got      SExpr
+}
+
+func (e TypeError) Error() string {
+	got := "no value"
+	if e.Got != nil && !IsVoid(e.Got) {
+		got = e.Got.String()
+	}
+	if e.Arg == 0 {
+		return fmt.Sprintf("%s: argument must be %s, got %s", e.Fn, e.Expected, got)
+	}
+	return fmt.Sprintf("%s: argument #%d must be %s, got %s", e.Fn, e.Arg, e.Expected, got)
+}
+
+// NotCallableError is returned when the first element of an evaluated list is not a function
+type NotCallableError struct {
+	Value SExpr
+	Pos   Position
+}
+
+func (e NotCallableError) Error() string {
+	return fmt.Sprintf("can not call `%v` as a function", e.Value)
+}
@@ -1,12 +1,18 @@
 package core
 
+import "fmt"
+
 // Fn is a universal function type
 type Fn struct {
-	srcName string
-	line    uint
-	pos     uint
-	name    string
-	fn      func(scope Scope, args ...SExpr) (SExpr, error)
+	// span is where the function was defined
+	span Span
+	name string
+	// doc is the documentation shown by the REPL
+	doc string
+	// evalArgs is set for functions which receive the values of their arguments
+	// (lambdas), builtins get the unevaluated expressions instead
+	evalArgs bool
+	fn       func(scope Scope, args ...SExpr) (SExpr, error)
 }
 
 // compile-time interface checks
@@ -19,14 +25,88 @@ func (fn Fn) Eval(_ Scope) (SExpr, error) {
 	return fn, nil
 }
 
+// Invoke calls the function with unevaluated argument expressions
 func (fn Fn) Invoke(scope Scope, args ...SExpr) (SExpr, error) {
-	return fn.fn(scope, args...)
+	if fn.evalArgs {
+		var err error
+		args, err = fn.evalArgList(scope, args)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return fn.call(scope, args)
+}
+
+// Apply calls the function with argument values, unlike Invoke it doesn't evaluate them.
+// Builtins which expect expressions get the values quoted.
+func (fn Fn) Apply(scope Scope, args ...SExpr) (SExpr, error) {
+	if !fn.evalArgs {
+		quoted := make([]SExpr, len(args))
+		for i, a := range args {
+			quoted[i] = FromSlice([]SExpr{Symbol{name: "quote"}, a})
+		}
+		args = quoted
+	}
+
+	return fn.call(scope, args)
+}
+
+// evalArgList evaluates the argument expressions of a call
+func (fn Fn) evalArgList(scope Scope, args []SExpr) ([]SExpr, error) {
+	name := fn.name
+	if name == "" {
+		name = "lambda"
+	}
+
+	vals := make([]SExpr, len(args))
+	for i, a := range args {
+		v, err := a.Eval(scope)
+		if err != nil {
+			return nil, fmt.Errorf("%s: argument #%d evaluation error: %w", name, i+1, err)
+		}
+		vals[i] = v
+	}
+
+	return vals, nil
+}
+
+// Name returns the name of the function, it is empty for anonymous functions
+func (fn Fn) Name() string {
+	return fn.name
+}
+
+// Doc returns the documentation of the function, it is empty for lambdas
+func (fn Fn) Doc() string {
+	return fn.doc
+}
+
+// WithDoc returns the function with the given documentation
+func (fn Fn) WithDoc(doc string) Fn {
+	fn.doc = doc
+	return fn
+}
+
+// Pos returns where the function was defined, it is zero for builtins
+func (fn Fn) Pos() Position {
+	return fn.span.Start
+}
+
+func (fn Fn) Span() Span {
+	return fn.span
 }
 
 func (fn Fn) String() string {
-	loc := location(fn.srcName, fn.line, fn.pos)
+	loc := fn.span.Start.String()
 	if fn.name != "" {
 		return "function " + fn.name + " @ " + loc
 	}
 	return "function @ " + loc
 }
+
+// NewFn creates a builtin function named name.
+// Like the builtins in this package fn receives its arguments unevaluated
+// (which allows writing special forms), call Eval on them to get their values.
+func NewFn(name string, fn func(scope Scope, args ...SExpr) (SExpr, error)) Fn {
+	return Fn{name: name, fn: fn}
+}
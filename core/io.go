@@ -0,0 +1,269 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// compile-time interface check
+var _ SExpr = new(Port)
+
+// Port is a source of input or a destination of output.
+// All the I/O builtins read from and write to ports, by default the current ones.
+type Port struct {
+	name string
+	r    *bufio.Reader
+	w    io.Writer
+}
+
+// NewInputPort creates a port reading from r
+func NewInputPort(name string, r io.Reader) Port {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return Port{name: name, r: br}
+}
+
+// NewOutputPort creates a port writing to w
+func NewOutputPort(name string, w io.Writer) Port {
+	return Port{name: name, w: w}
+}
+
+var (
+	stdinPort  = NewInputPort("stdin", os.Stdin)
+	stdoutPort = NewOutputPort("stdout", os.Stdout)
+	stderrPort = NewOutputPort("stderr", os.Stderr)
+)
+
+// Eval returns the port itself
+func (p Port) Eval(_ Scope) (SExpr, error) {
+	return p, nil
+}
+
+func (p Port) String() string {
+	if p.r != nil {
+		return "#<input-port " + p.name + ">"
+	}
+	return "#<output-port " + p.name + ">"
+}
+
+func (p Port) Pos() Position {
+	return Position{}
+}
+
+func (p Port) Span() Span {
+	return Span{}
+}
+
+// ports are the current ports of a session, a nil reader or writer means the default one
+type ports struct {
+	in, out, err Port
+}
+
+// WithPorts returns a scope with the same bindings whose current ports
+// read from in and write to out and errOut. Nil arguments keep the current ports.
+func (scope Scope) WithPorts(in io.Reader, out, errOut io.Writer) Scope {
+	s := session{}
+	if scope.session != nil {
+		s = *scope.session
+	}
+	if in != nil {
+		s.ports.in = NewInputPort("input", in)
+	}
+	if out != nil {
+		s.ports.out = NewOutputPort("output", out)
+	}
+	if errOut != nil {
+		s.ports.err = NewOutputPort("error", errOut)
+	}
+	scope.session = &s
+
+	return scope
+}
+
+func (scope Scope) inputPort() Port {
+	if scope.session != nil && scope.session.ports.in.r != nil {
+		return scope.session.ports.in
+	}
+	return stdinPort
+}
+
+func (scope Scope) outputPort() Port {
+	if scope.session != nil && scope.session.ports.out.w != nil {
+		return scope.session.ports.out
+	}
+	return stdoutPort
+}
+
+func (scope Scope) errorPort() Port {
+	if scope.session != nil && scope.session.ports.err.w != nil {
+		return scope.session.ports.err
+	}
+	return stderrPort
+}
+
+// writeTo writes s to the port respecting the output limit
+func (scope Scope) writeTo(p Port, s string) error {
+	if p.w == nil {
+		return errors.New(fmt.Sprintf("%v is not an output port", p))
+	}
+	if err := scope.useOutput(len(s)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(p.w, s)
+
+	return err
+}
+
+func currentInputPort(scope Scope, args ...SExpr) (SExpr, error) {
+	if err := checkArity("current-input-port", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return scope.inputPort(), nil
+}
+
+func currentOutputPort(scope Scope, args ...SExpr) (SExpr, error) {
+	if err := checkArity("current-output-port", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return scope.outputPort(), nil
+}
+
+func currentErrorPort(scope Scope, args ...SExpr) (SExpr, error) {
+	if err := checkArity("current-error-port", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return scope.errorPort(), nil
+}
+
+// portArg evaluates the optional port argument of I/O builtins
+func portArg(name string, scope Scope, args []SExpr, i int, def Port) (Port, error) {
+	if len(args) <= i {
+		return def, nil
+	}
+	v, err := args[i].Eval(scope)
+	if err != nil {
+		return Port{}, fmt.Errorf("%s: argument #%d evaluation error: %w", name, i+1, err)
+	}
+	p, ok := v.(Port)
+	if !ok {
+		return Port{}, TypeError{Fn: name, Arg: i + 1, Expected: "a port", Got: v}
+	}
+
+	return p, nil
+}
+
+// write writes the value in a form which can be read back: (write "a") prints "a"
+func write(scope Scope, args ...SExpr) (SExpr, error) {
+	return writeValue("write", scope, args, func(v SExpr) string { return v.String() })
+}
+
+// display writes the value for humans: (display "a") prints a
+func display(scope Scope, args ...SExpr) (SExpr, error) {
+	return writeValue("display", scope, args, func(v SExpr) string {
+		if s, ok := v.(String); ok {
+			return s.val
+		}
+		return v.String()
+	})
+}
+
+func writeValue(name string, scope Scope, args []SExpr, format func(SExpr) string) (SExpr, error) {
+	if err := checkArity(name, args, 1, 2); err != nil {
+		return nil, err
+	}
+	v, err := args[0].Eval(scope)
+	if err != nil {
+		return nil, fmt.Errorf("%s: argument #1 evaluation error: %w", name, err)
+	}
+	p, err := portArg(name, scope, args, 1, scope.outputPort())
+	if err != nil {
+		return nil, err
+	}
+	if err := scope.writeTo(p, format(v)); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return Void, nil
+}
+
+// newline writes a line break
+func newline(scope Scope, args ...SExpr) (SExpr, error) {
+	if err := checkArity("newline", args, 0, 1); err != nil {
+		return nil, err
+	}
+	p, err := portArg("newline", scope, args, 0, scope.outputPort())
+	if err != nil {
+		return nil, err
+	}
+	if err := scope.writeTo(p, "\n"); err != nil {
+		return nil, fmt.Errorf("newline: %w", err)
+	}
+
+	return Void, nil
+}
+
+// readLine reads a line from the port and returns it as a string without the line break.
+// At the end of input it returns ().
+func readLine(scope Scope, args ...SExpr) (SExpr, error) {
+	if err := checkArity("read-line", args, 0, 1); err != nil {
+		return nil, err
+	}
+	p, err := portArg("read-line", scope, args, 0, scope.inputPort())
+	if err != nil {
+		return nil, err
+	}
+	if p.r == nil {
+		return nil, TypeError{Fn: "read-line", Arg: 1, Expected: "an input port", Got: p}
+	}
+
+	line, err := p.r.ReadString('\n')
+	if errors.Is(err, io.EOF) && line == "" {
+		return False, nil
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("read-line: %w", err)
+	}
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+	s := String{val: line}
+	if err := scope.checkSize(s); err != nil {
+		return nil, fmt.Errorf("read-line: %w", err)
+	}
+
+	return s, nil
+}
+
+// withOutputToString evaluates its body with the current output port
+// writing to a string and returns that string
+func withOutputToString(scope Scope, args ...SExpr) (SExpr, error) {
+	if scope.session == nil {
+		scope.session = &session{}
+	}
+	s := scope.session
+
+	var buf bytes.Buffer
+	prev := s.ports.out
+	s.ports.out = NewOutputPort("string", &buf)
+	defer func() { s.ports.out = prev }()
+
+	for i, a := range args {
+		if _, err := a.Eval(scope); err != nil {
+			return nil, fmt.Errorf("with-output-to-string: expression #%d: %w", i+1, err)
+		}
+	}
+
+	out := String{val: buf.String()}
+	if err := scope.checkSize(out); err != nil {
+		return nil, fmt.Errorf("with-output-to-string: %w", err)
+	}
+
+	return out, nil
+}
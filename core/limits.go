@@ -0,0 +1,153 @@
+package core
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Limits caps the resources a single evaluation may consume.
+// They are meant for running untrusted code: once a limit is hit
+// the evaluation stops with a LimitError and the host keeps running.
+// A zero field means "unlimited".
+type Limits struct {
+	// MaxSteps is the number of list evaluations (function calls) allowed
+	MaxSteps uint64
+	// MaxConses is the number of cons cells `cons` is allowed to allocate
+	MaxConses uint64
+	// MaxListLen is the maximum number of elements in a list produced by evaluation
+	MaxListLen int
+	// MaxStringLen is the maximum length of a string or a symbol name in runes
+	MaxStringLen int
+	// MaxOutputBytes is the number of bytes `print` is allowed to write
+	MaxOutputBytes int
+}
+
+// LimitError reports which limit was exceeded. Use errors.As to detect it.
+type LimitError struct {
+	// Limit is the name of the exceeded Limits field
+	Limit string
+	Max   uint64
+}
+
+func (e LimitError) Error() string {
+	return fmt.Sprintf("limit exceeded: %s is %d", e.Limit, e.Max)
+}
+
+// usage counts the resources consumed by the current evaluation
+type usage struct {
+	steps   uint64
+	conses  uint64
+	written int
+}
+
+// WithLimits returns a scope with the same bindings which enforces l on everything
+// evaluated in it. Counters start from zero, so every call begins a new evaluation:
+//
+//	result, err := expr.Eval(scope.WithLimits(core.Limits{MaxSteps: 10000}))
+func (scope Scope) WithLimits(l Limits) Scope {
+	s := session{}
+	if scope.session != nil {
+		s = *scope.session
+	}
+	s.limits = l
+	s.usage = usage{}
+	scope.session = &s
+
+	return scope
+}
+
+// step accounts for one evaluation step
+func (scope Scope) step() error {
+	s := scope.session
+	if s == nil || s.limits.MaxSteps == 0 {
+		return nil
+	}
+	s.usage.steps++
+	if s.usage.steps > s.limits.MaxSteps {
+		return LimitError{Limit: "MaxSteps", Max: s.limits.MaxSteps}
+	}
+
+	return nil
+}
+
+// allocCons accounts for one allocated cons cell
+func (scope Scope) allocCons() error {
+	s := scope.session
+	if s == nil || s.limits.MaxConses == 0 {
+		return nil
+	}
+	s.usage.conses++
+	if s.usage.conses > s.limits.MaxConses {
+		return LimitError{Limit: "MaxConses", Max: s.limits.MaxConses}
+	}
+
+	return nil
+}
+
+// useOutput accounts for n bytes of output, it must be called before writing them
+func (scope Scope) useOutput(n int) error {
+	s := scope.session
+	if s == nil || s.limits.MaxOutputBytes == 0 {
+		return nil
+	}
+	if s.usage.written+n > s.limits.MaxOutputBytes {
+		return LimitError{Limit: "MaxOutputBytes", Max: uint64(s.limits.MaxOutputBytes)}
+	}
+	s.usage.written += n
+
+	return nil
+}
+
+// checkAlloc verifies that a value built outside of evaluation, e.g. by a Go function,
+// fits the size limits and accounts for its cons cells
+func (scope Scope) checkAlloc(v SExpr) error {
+	if err := scope.checkSize(v); err != nil {
+		return err
+	}
+	l, ok := v.(List)
+	if !ok {
+		return nil
+	}
+	for item := range l.Items() {
+		if err := scope.allocCons(); err != nil {
+			return err
+		}
+		if err := scope.checkAlloc(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkSize verifies that a value produced by evaluation fits the size limits
+func (scope Scope) checkSize(v SExpr) error {
+	s := scope.session
+	if s == nil {
+		return nil
+	}
+
+	switch v := v.(type) {
+	case Symbol:
+		if s.limits.MaxStringLen > 0 && utf8.RuneCountInString(v.name) > s.limits.MaxStringLen {
+			return LimitError{Limit: "MaxStringLen", Max: uint64(s.limits.MaxStringLen)}
+		}
+	case String:
+		if s.limits.MaxStringLen > 0 && utf8.RuneCountInString(v.val) > s.limits.MaxStringLen {
+			return LimitError{Limit: "MaxStringLen", Max: uint64(s.limits.MaxStringLen)}
+		}
+	case List:
+		if s.limits.MaxListLen == 0 {
+			return nil
+		}
+		n := 0
+		for range v.Items() {
+			n++
+			if n > s.limits.MaxListLen {
+				return LimitError{Limit: "MaxListLen", Max: uint64(s.limits.MaxListLen)}
+			}
+		}
+	}
+
+	return nil
+}
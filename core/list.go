@@ -1,6 +1,7 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"iter"
 	"strconv"
@@ -17,28 +18,41 @@ type List struct {
 	// first item in pair, traditionally called "car"
 	first SExpr
 	// second item in pair, traditionally called "cdr"
-	second  SExpr
-	srcName string
-	line    uint
-	pos     uint
+	second SExpr
+	span   Span
 }
 
 func NewList(srcName string, line, pos uint, els ...SExpr) List {
+	return newList(Span{Start: Position{SrcName: srcName, Line: line, Col: pos}}, els)
+}
+
+func newList(span Span, els []SExpr) List {
 	var prev List
 	for i := len(els) - 1; i >= 0; i-- {
 		p := List{
-			first:   els[i],
-			second:  prev,
-			srcName: srcName,
-			line:    line,
-			pos:     pos,
+			first:  els[i],
+			second: prev,
+			span:   span,
 		}
 		prev = p
 	}
+	// an empty list keeps its position too
+	prev.span = span
 
 	return prev
 }
 
+// WithSpan returns the list read from the given part of the source.
+// The span spans from the opening to the closing parenthesis.
+func (l List) WithSpan(span Span) List {
+	return newList(span, l.Flatten())
+}
+
+// FromSlice builds a list of items which has no source position
+func FromSlice(items []SExpr) List {
+	return NewList("", 0, 0, items...)
+}
+
 // Eval returns the value of a list S-expression.
 // Usually a form like `(f a b c)` is called a "function call" but depending
 // on what f is different rules may apply (specifically for lambda, label and defun).
@@ -51,22 +65,39 @@ func (l List) Eval(scope Scope) (SExpr, error) {
 		return l, nil
 	}
 
+	if err := scope.step(); err != nil {
+		return nil, l.error(Fn{}, err)
+	}
+
 	items := l.Flatten()
 
 	// get the function to evaluate
 	fnSExpr, err := items[0].Eval(scope)
 	if err != nil {
-		return nil, l.error("", err)
+		return nil, l.error(Fn{}, err)
 	}
 	fn, ok := fnSExpr.(Fn)
 	if !ok {
-		return nil, l.error(fmt.Sprintf("can not call `%v` as a function", fnSExpr), nil)
+		return nil, l.error(Fn{}, NotCallableError{Value: fnSExpr, Pos: l.Pos()})
+	}
+
+	// pass arguments to the Fn (unevaluated unless it asks for values)
+	args := items[1:]
+	if fn.evalArgs {
+		args, err = fn.evalArgList(scope, args)
+		if err != nil {
+			return nil, l.error(fn, err)
+		}
 	}
 
-	// pass arguments to the Fn (unevaluated)
-	result, err := fn.Invoke(scope, items[1:]...)
+	var result SExpr
+	if scope.observed() {
+		result, err = scope.observedCall(Call{Fn: fn, Form: l, Args: args})
+	} else {
+		result, err = fn.call(scope, args)
+	}
 	if err != nil {
-		return nil, l.error("", err)
+		return nil, l.error(fn, err)
 	}
 
 	return result, nil
@@ -128,6 +159,37 @@ func (l List) Items() iter.Seq[SExpr] {
 	}
 }
 
+// Len returns the number of elements in the list
+func (l List) Len() int {
+	n := 0
+	for range l.Items() {
+		n++
+	}
+
+	return n
+}
+
+// Nth returns the i-th element of the list (counting from 0)
+// and false if the list is shorter than that
+func (l List) Nth(i int) (SExpr, bool) {
+	if i < 0 {
+		return nil, false
+	}
+	for item := range l.Items() {
+		if i == 0 {
+			return item, true
+		}
+		i--
+	}
+
+	return nil, false
+}
+
+// ToSlice returns the elements of the list
+func (l List) ToSlice() []SExpr {
+	return l.Flatten()
+}
+
 func (l List) Flatten() []SExpr {
 	items := []SExpr{}
 	for item := range l.Items() {
@@ -137,6 +199,14 @@ func (l List) Flatten() []SExpr {
 	return items
 }
 
+func (l List) Pos() Position {
+	return l.span.Start
+}
+
+func (l List) Span() Span {
+	return l.span
+}
+
 func (l List) String() string {
 	var b strings.Builder
 	b.WriteRune('(')
@@ -152,45 +222,19 @@ func (l List) String() string {
 	return b.String()
 }
 
-func (l List) error(msg string, err error) ListEvalError {
-	return ListEvalError{
-		srcName:    l.srcName,
-		line:       l.line,
-		pos:        l.pos,
-		wrappedErr: err,
-		msg:        msg,
-	}
-}
-
-type ListEvalError struct {
-	srcName    string
-	line       uint
-	pos        uint
-	wrappedErr error
-	msg        string
-}
-
-func (e ListEvalError) Error() string {
-	var b strings.Builder
-
-	b.WriteString(location(e.srcName, e.line, e.pos))
-	b.WriteString(": evaluation error")
+// error attaches the call frame of the list to err.
+// If err already carries a backtrace the frame is appended to it,
+// otherwise a new EvalError is created.
+func (l List) error(fn Fn, err error) *EvalError {
+	frame := Frame{Fn: fn.name, Form: l, Pos: l.Pos()}
 
-	if e.msg != "" {
-		b.WriteString(": ")
-		b.WriteString(e.msg)
+	var evalErr *EvalError
+	if errors.As(err, &evalErr) {
+		evalErr.Frames = append(evalErr.Frames, frame)
+		return evalErr
 	}
 
-	if e.wrappedErr != nil {
-		b.WriteString(": ")
-		b.WriteString(e.wrappedErr.Error())
-	}
-
-	return b.String()
-}
-
-func (e ListEvalError) Unwrap() error {
-	return e.wrappedErr
+	return &EvalError{Err: err, Frames: []Frame{frame}}
 }
 
 // location formats a source location for error messages.
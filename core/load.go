@@ -0,0 +1,53 @@
+package core
+
+import "iter"
+
+// LoadAtomic evaluates forms read from srcName and keeps their definitions
+// only if all of them succeed. The forms are evaluated in a staging layer
+// on top of scope which is committed at the end. Evaluation goes on after
+// a failure, so the returned *LoadError lists all of them. Reading stops
+// at the first error of forms.
+// evaluated, unless nil, is called with every form which succeeded and its value.
+func (scope Scope) LoadAtomic(srcName string, forms iter.Seq2[SExpr, error], evaluated func(form, result SExpr)) error {
+	staging := scope.NewLayer()
+	errs := []error{}
+	for e, err := range forms {
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+		result, err := e.Eval(staging)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if evaluated != nil {
+			evaluated(e, result)
+		}
+	}
+	if len(errs) > 0 {
+		return &LoadError{SrcName: srcName, Errs: errs}
+	}
+	scope.Commit(staging)
+
+	return nil
+}
+
+// DefinedName returns the name defined by a (defun name ...) or (label name ...) form
+func DefinedName(e SExpr) (string, bool) {
+	l, ok := e.(List)
+	if !ok {
+		return "", false
+	}
+	head, ok := l.First().(Symbol)
+	if !ok || (head.name != "defun" && head.name != "label") {
+		return "", false
+	}
+	nameArg, _ := l.Nth(1)
+	name, ok := nameArg.(Symbol)
+	if !ok {
+		return "", false
+	}
+
+	return name.name, true
+}
@@ -0,0 +1,396 @@
+package core
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var sexprType = reflect.TypeFor[SExpr]()
+
+// nilName is the symbol nil pointers marshal to
+const nilName = "nil"
+
+// Marshal converts a Go value to an S-expression:
+//
+//   - strings become strings, numbers become symbols like 42 or 0.5
+//   - bools become t and ()
+//   - slices and arrays become lists, nil pointers become the symbol nil
+//     to tell them from pointers to empty values
+//   - maps and structs become association lists ((key value) ...) as used by `assoc.`,
+//     map keys are sorted and keep their types, so string keys are strings,
+//     struct keys are symbols taken from `sexpr:"key"` field tags
+//     or the lowercased field names. The tag "-" skips a field and
+//     the option ",omitempty" skips it when it has a zero value.
+//   - values implementing SExpr are returned as is
+func Marshal(v any) (SExpr, error) {
+	if v == nil {
+		return False, nil
+	}
+
+	return fromGo(reflect.ValueOf(v))
+}
+
+// Unmarshal stores the Go value represented by e in the value pointed to by v.
+// It reverses Marshal, so a round trip is lossless except that
+// nil slices come back empty, as Lisp has one empty list. () converts
+// to a nil pointer only if the pointed to type can't be empty.
+// Additionally symbols convert to strings and structs can be read
+// from property lists (key value ...).
+// Unknown keys are ignored.
+func Unmarshal(e SExpr, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New(fmt.Sprintf("unmarshal: expects a non-nil pointer, got %T", v))
+	}
+
+	val, err := toGo(e, rv.Elem().Type())
+	if err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+	rv.Elem().Set(val)
+
+	return nil
+}
+
+// toGo converts an S-expression to a Go value of type t
+func toGo(v SExpr, t reflect.Type) (reflect.Value, error) {
+	if v == nil || IsVoid(v) {
+		return reflect.Value{}, errors.New(fmt.Sprintf("cannot convert no value to %s", t))
+	}
+	// S-expressions are passed as is
+	if reflect.TypeOf(v).AssignableTo(t) && t != reflect.TypeFor[any]() {
+		return reflect.ValueOf(v), nil
+	}
+
+	cannotConvert := errors.New(fmt.Sprintf("cannot convert %v to %s", v, t))
+	rv := reflect.New(t).Elem()
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if s, ok := v.(Symbol); ok && s.name == nilName {
+			return rv, nil
+		}
+		elem, err := toGo(v, t.Elem())
+		if l, ok := v.(List); ok && l.IsEmpty() && err != nil {
+			return rv, nil
+		}
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv.Set(reflect.New(t.Elem()))
+		rv.Elem().Set(elem)
+		return rv, nil
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			return reflect.Value{}, cannotConvert
+		}
+		return toAny(v, t)
+	}
+
+	switch l := v.(type) {
+	case String:
+		if t.Kind() != reflect.String {
+			return reflect.Value{}, cannotConvert
+		}
+		rv.SetString(l.val)
+		return rv, nil
+	case List:
+		switch t.Kind() {
+		case reflect.Bool:
+			if !l.IsEmpty() {
+				return reflect.Value{}, cannotConvert
+			}
+			return rv, nil
+		case reflect.Slice:
+			rv = reflect.MakeSlice(t, 0, l.Len())
+			for item := range l.Items() {
+				ev, err := toGo(item, t.Elem())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				rv = reflect.Append(rv, ev)
+			}
+			return rv, nil
+		case reflect.Array:
+			i := 0
+			for item := range l.Items() {
+				if i >= t.Len() {
+					return reflect.Value{}, cannotConvert
+				}
+				ev, err := toGo(item, t.Elem())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				rv.Index(i).Set(ev)
+				i++
+			}
+			return rv, nil
+		case reflect.Map:
+			if l.IsEmpty() {
+				return rv, nil
+			}
+			rv.Set(reflect.MakeMap(t))
+			return rv, eachPair(l, func(key, val SExpr) error {
+				kv, err := toGo(key, t.Key())
+				if err != nil {
+					return err
+				}
+				vv, err := toGo(val, t.Elem())
+				if err != nil {
+					return err
+				}
+				rv.SetMapIndex(kv, vv)
+				return nil
+			})
+		case reflect.Struct:
+			fields := structFields(t)
+			return rv, eachPair(l, func(key, val SExpr) error {
+				name, ok := keyName(key)
+				if !ok {
+					return errors.New(fmt.Sprintf("cannot use %v as a key of %s", key, t))
+				}
+				i := slices.IndexFunc(fields, func(f field) bool { return f.key == name })
+				if i < 0 {
+					return nil
+				}
+				fv, err := toGo(val, t.Field(fields[i].index).Type)
+				if err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				rv.Field(fields[i].index).Set(fv)
+				return nil
+			})
+		}
+		return reflect.Value{}, cannotConvert
+	case Symbol:
+		var err error
+		switch t.Kind() {
+		case reflect.String:
+			rv.SetString(l.name)
+		case reflect.Bool:
+			if l.name != True.name {
+				return reflect.Value{}, cannotConvert
+			}
+			rv.SetBool(true)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			var n int64
+			n, err = strconv.ParseInt(l.name, 10, t.Bits())
+			rv.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			var n uint64
+			n, err = strconv.ParseUint(l.name, 10, t.Bits())
+			rv.SetUint(n)
+		case reflect.Float32, reflect.Float64:
+			var f float64
+			f, err = strconv.ParseFloat(l.name, t.Bits())
+			rv.SetFloat(f)
+		default:
+			return reflect.Value{}, cannotConvert
+		}
+		if err != nil {
+			return reflect.Value{}, cannotConvert
+		}
+		return rv, nil
+	}
+
+	return reflect.Value{}, cannotConvert
+}
+
+// toAny converts an S-expression to the "natural" Go type for an empty interface:
+// strings and symbols to string, t to true, lists to []any and () to nil
+func toAny(v SExpr, t reflect.Type) (reflect.Value, error) {
+	rv := reflect.New(t).Elem()
+	var val any
+	switch v := v.(type) {
+	case String:
+		val = v.val
+	case Symbol:
+		if v.name == True.name {
+			val = true
+		} else {
+			val = v.name
+		}
+	case List:
+		if v.IsEmpty() {
+			return rv, nil
+		}
+		items := []any{}
+		for item := range v.Items() {
+			iv, err := toAny(item, t)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			items = append(items, iv.Interface())
+		}
+		val = items
+	default:
+		val = v
+	}
+	rv.Set(reflect.ValueOf(val))
+
+	return rv, nil
+}
+
+// fromGo converts a Go value to an S-expression
+func fromGo(rv reflect.Value) (SExpr, error) {
+	if rv.Type().Implements(sexprType) && !(rv.Kind() == reflect.Interface && rv.IsNil()) {
+		return rv.Interface().(SExpr), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return String{val: rv.String()}, nil
+	case reflect.Bool:
+		if rv.Bool() {
+			return True, nil
+		}
+		return False, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Symbol{name: strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Symbol{name: strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return Symbol{name: strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits())}, nil
+	case reflect.Slice, reflect.Array:
+		items := make([]SExpr, rv.Len())
+		for i := range rv.Len() {
+			item, err := fromGo(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return NewList("", 0, 0, items...), nil
+	case reflect.Map:
+		keys := rv.MapKeys()
+		pairs := make([]SExpr, 0, len(keys))
+		for _, k := range keys {
+			key, err := fromGo(k)
+			if err != nil {
+				return nil, err
+			}
+			val, err := fromGo(rv.MapIndex(k))
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, NewList("", 0, 0, key, val))
+		}
+		slices.SortFunc(pairs, func(a, b SExpr) int {
+			return cmp.Compare(a.(List).first.String(), b.(List).first.String())
+		})
+		return NewList("", 0, 0, pairs...), nil
+	case reflect.Struct:
+		pairs := []SExpr{}
+		for _, f := range structFields(rv.Type()) {
+			fv := rv.Field(f.index)
+			if f.omitEmpty && fv.IsZero() {
+				continue
+			}
+			val, err := fromGo(fv)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.key, err)
+			}
+			pairs = append(pairs, NewList("", 0, 0, Symbol{name: f.key}, val))
+		}
+		return NewList("", 0, 0, pairs...), nil
+	case reflect.Interface:
+		if rv.IsNil() {
+			return False, nil
+		}
+		return fromGo(rv.Elem())
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return Symbol{name: nilName}, nil
+		}
+		return fromGo(rv.Elem())
+	}
+
+	return nil, errors.New(fmt.Sprintf("cannot convert %s to an S-expression", rv.Type()))
+}
+
+// field describes how a struct field is marshaled
+type field struct {
+	index     int
+	key       string
+	omitEmpty bool
+}
+
+func structFields(t reflect.Type) []field {
+	fields := []field{}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("sexpr")
+		if tag == "-" {
+			continue
+		}
+		key, opts, _ := strings.Cut(tag, ",")
+		if key == "" {
+			key = strings.ToLower(f.Name)
+		}
+		fields = append(fields, field{
+			index:     i,
+			key:       key,
+			omitEmpty: opts == "omitempty",
+		})
+	}
+
+	return fields
+}
+
+// eachPair calls fn for every key and value of an association list ((k v) ...)
+// or a property list (k v ...)
+func eachPair(l List, fn func(key, val SExpr) error) error {
+	items := l.Flatten()
+	if len(items) == 0 {
+		return nil
+	}
+
+	if _, isAlist := items[0].(List); isAlist {
+		for _, item := range items {
+			pair, ok := item.(List)
+			if !ok {
+				return errors.New(fmt.Sprintf("association list entry %v is not a list", item))
+			}
+			kv := pair.Flatten()
+			if len(kv) != 2 {
+				return errors.New(fmt.Sprintf("association list entry %v must have 2 elements, has %d", item, len(kv)))
+			}
+			if err := fn(kv[0], kv[1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(items)%2 != 0 {
+		return errors.New(fmt.Sprintf("property list %v has an odd number of elements", l))
+	}
+	for i := 0; i < len(items); i += 2 {
+		if err := fn(items[i], items[i+1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keyName returns the name of a key in an association or property list.
+// Keys of property lists may be written as keywords like :name.
+func keyName(key SExpr) (string, bool) {
+	switch k := key.(type) {
+	case Symbol:
+		return strings.TrimPrefix(k.name, ":"), true
+	case String:
+		return k.val, true
+	}
+
+	return "", false
+}
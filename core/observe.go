@@ -0,0 +1,208 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Call describes a function call reported to an Observer
+type Call struct {
+	Fn Fn
+	// Form is the list being evaluated, its position is the location of the call
+	Form List
+	// Args are the argument values for functions which evaluate their arguments
+	// (lambdas) and the unevaluated expressions for the rest of builtins
+	Args []SExpr
+	// Depth is the number of calls in progress, 0 for the outermost one
+	Depth int
+}
+
+// Observer is notified about what evaluated code does.
+// Embed NopObserver to implement only the methods you need.
+type Observer interface {
+	// Enter is called before a function is called
+	Enter(call Call)
+	// Exit is called after a function returned successfully
+	Exit(call Call, result SExpr)
+	// Error is called when a function returned an error
+	Error(call Call, err error)
+	// Bind is called when a value is bound to a name in any scope layer
+	Bind(name string, value SExpr)
+}
+
+// NopObserver ignores all notifications
+type NopObserver struct{}
+
+func (NopObserver) Enter(Call)         {}
+func (NopObserver) Exit(Call, SExpr)   {}
+func (NopObserver) Error(Call, error)  {}
+func (NopObserver) Bind(string, SExpr) {}
+
+// tracing holds the state of the `trace` builtin,
+// it's shared by all copies of the session
+type tracing struct {
+	fns   map[string]bool
+	depth int
+}
+
+func newTracing() *tracing {
+	return &tracing{fns: map[string]bool{}}
+}
+
+// WithObserver returns a scope with the same bindings reporting evaluation to o.
+// When no observer is registered (and nothing is traced) the only overhead is a nil check.
+func (scope Scope) WithObserver(o Observer) Scope {
+	s := session{}
+	if scope.session != nil {
+		s = *scope.session
+	}
+	s.observer = o
+	scope.session = &s
+
+	return scope
+}
+
+// observed reports whether calls need to be reported to an observer or traced
+func (scope Scope) observed() bool {
+	s := scope.session
+	return s != nil && (s.observer != nil || (s.tracing != nil && len(s.tracing.fns) > 0))
+}
+
+// observedCall calls call.Fn with call.Args notifying the observer and printing traces
+func (scope Scope) observedCall(call Call) (SExpr, error) {
+	s := scope.session
+	call.Depth = s.depth
+	s.depth++
+	defer func() { s.depth-- }()
+
+	traced := s.tracing != nil && s.tracing.fns[call.Fn.name]
+	if traced {
+		if err := scope.traceEnter(call); err != nil {
+			return nil, err
+		}
+	}
+	if s.observer != nil {
+		s.observer.Enter(call)
+	}
+
+	result, err := call.Fn.call(scope, call.Args)
+
+	if s.observer != nil {
+		if err != nil {
+			s.observer.Error(call, err)
+		} else {
+			s.observer.Exit(call, result)
+		}
+	}
+	if traced {
+		if err := scope.traceExit(call, result, err); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, err
+}
+
+// traceEnter prints a traced call indented by the depth of traced calls
+//
+//	0: (fact 3)
+//	  1: (fact 2)
+func (scope Scope) traceEnter(call Call) error {
+	t := scope.session.tracing
+	items := []string{call.Fn.name}
+	for _, a := range call.Args {
+		items = append(items, a.String())
+	}
+	line := fmt.Sprintf("%s%d: (%s)\n", strings.Repeat("  ", t.depth), t.depth, strings.Join(items, " "))
+	if err := scope.writeTo(scope.outputPort(), line); err != nil {
+		// the call isn't made, so there is no exit to undo the depth
+		return err
+	}
+	t.depth++
+
+	return nil
+}
+
+// traceExit prints the result of a traced call
+//
+//	  1: fact returned 2
+//	0: fact returned 6
+func (scope Scope) traceExit(call Call, result SExpr, err error) error {
+	t := scope.session.tracing
+	t.depth--
+	outcome := "returned "
+	switch {
+	case err != nil:
+		outcome = "failed"
+	case IsVoid(result):
+		outcome = "returned no value"
+	default:
+		outcome += result.String()
+	}
+	line := fmt.Sprintf("%s%d: %s %s\n", strings.Repeat("  ", t.depth), t.depth, call.Fn.name, outcome)
+
+	return scope.writeTo(scope.outputPort(), line)
+}
+
+// trace prints calls of the named functions and their results.
+// (trace f g) starts tracing f and g, (trace) returns the list of traced functions.
+func trace(scope Scope, args ...SExpr) (SExpr, error) {
+	if scope.session == nil {
+		return nil, errors.New("trace: tracing is not supported in this scope")
+	}
+	if scope.session.tracing == nil {
+		scope.session.tracing = newTracing()
+	}
+	t := scope.session.tracing
+
+	for i, a := range args {
+		sym, ok := a.(Symbol)
+		if !ok {
+			return nil, TypeError{Fn: "trace", Arg: i + 1, Expected: "a function name", Got: a}
+		}
+		t.fns[sym.name] = true
+	}
+
+	return tracedList(t), nil
+}
+
+// untrace stops tracing the named functions, (untrace) stops tracing all of them
+func untrace(scope Scope, args ...SExpr) (SExpr, error) {
+	if scope.session == nil {
+		return False, nil
+	}
+	if scope.session.tracing == nil {
+		scope.session.tracing = newTracing()
+	}
+	t := scope.session.tracing
+
+	if len(args) == 0 {
+		clear(t.fns)
+	}
+	for i, a := range args {
+		sym, ok := a.(Symbol)
+		if !ok {
+			return nil, TypeError{Fn: "untrace", Arg: i + 1, Expected: "a function name", Got: a}
+		}
+		delete(t.fns, sym.name)
+	}
+
+	return tracedList(t), nil
+}
+
+func tracedList(t *tracing) List {
+	names := []string{}
+	for name := range t.fns {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	items := make([]SExpr, len(names))
+	for i, name := range names {
+		items[i] = Symbol{name: name}
+	}
+
+	return FromSlice(items)
+}
@@ -0,0 +1,208 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// UserPackage is the package code is evaluated in until `in-package` switches it.
+// Its names are not qualified, builtins are defined in it too.
+const UserPackage = "user"
+
+// Packages are namespaces for definitions, like the packages of Common Lisp:
+//
+//	(defpackage list (second))  ; exports second
+//	(in-package list)
+//	(defun rest (x) (cdr x))    ; binds list:rest
+//	(defun second (x) (car (rest x)))
+//	(in-package user)
+//	(list:second '(a b))        ; list:rest needs list::rest
+//
+// A name defined in a package is bound as `package:name`. Unqualified names
+// are looked up in the current package first and then in the user package.
+// Functions remember the package they were created in, so their bodies
+// see its names wherever they are called from.
+type packages struct {
+	current string
+	// exports maps the names of the defined packages to their exported names
+	exports map[string]map[string]bool
+}
+
+func newPackages() *packages {
+	return &packages{
+		current: UserPackage,
+		exports: map[string]map[string]bool{UserPackage: {}},
+	}
+}
+
+// PackageError is returned for a qualified symbol which can't be used
+type PackageError struct {
+	// Name is the qualified symbol
+	Name string
+	Pos  Position
+	Msg  string
+}
+
+func (e PackageError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Pos, e.Name, e.Msg)
+}
+
+// splitQualified splits `package:name` or `package::name`,
+// qualified is false for an unqualified name or a keyword like `:name`
+func splitQualified(sym string) (pkg, name string, internal, qualified bool) {
+	pkg, name, qualified = strings.Cut(sym, ":")
+	if !qualified || pkg == "" {
+		return "", sym, false, false
+	}
+	name, internal = strings.CutPrefix(name, ":")
+
+	return pkg, name, internal, true
+}
+
+// QualifiedName returns the name a definition of name in pkg is bound to
+func QualifiedName(pkg, name string) string {
+	if pkg == UserPackage || pkg == "" {
+		return name
+	}
+
+	return pkg + ":" + name
+}
+
+// Package returns the name of the package unqualified names are resolved in
+func (scope Scope) Package() string {
+	if scope.pkg != "" {
+		return scope.pkg
+	}
+	if scope.session == nil || scope.session.packages == nil {
+		return UserPackage
+	}
+
+	return scope.session.packages.current
+}
+
+// qualify returns the name a definition of name in the current package is bound to
+func (scope Scope) qualify(name string) string {
+	if pkg, name, _, ok := splitQualified(name); ok {
+		return QualifiedName(pkg, name)
+	}
+
+	return QualifiedName(scope.Package(), name)
+}
+
+// lookup returns the value of a symbol taking packages into account
+func (scope Scope) lookup(sym Symbol) (SExpr, bool, error) {
+	pkg, name, internal, qualified := splitQualified(sym.name)
+	if !qualified {
+		cur := scope.Package()
+		if cur == UserPackage {
+			v, ok := scope.SymbolValue(name)
+			return v, ok, nil
+		}
+		// inner layers (e.g. parameters) shadow the definitions of the package,
+		// which shadow the ones of the user package in the same layer
+		pkgName := QualifiedName(cur, name)
+		for layer := &scope; layer != nil; layer = layer.parent {
+			if v, ok := layer.vals[pkgName]; ok {
+				return v, true, nil
+			}
+			if v, ok := layer.vals[name]; ok {
+				return v, true, nil
+			}
+		}
+		return nil, false, nil
+	}
+
+	var exports map[string]bool
+	if scope.session != nil && scope.session.packages != nil {
+		exports = scope.session.packages.exports[pkg]
+	}
+	if exports == nil {
+		return nil, false, PackageError{Name: sym.name, Pos: sym.Pos(), Msg: "package " + pkg + " is not defined"}
+	}
+	if !internal && !exports[name] && pkg != scope.Package() {
+		return nil, false, PackageError{Name: sym.name, Pos: sym.Pos(), Msg: fmt.Sprintf("%s is not exported from %s, use %s::%s", name, pkg, pkg, name)}
+	}
+
+	v, ok := scope.SymbolValue(QualifiedName(pkg, name))
+	return v, ok, nil
+}
+
+// defpackage defines a package and the names it exports: (defpackage name (export ...)).
+// Defining a package again adds the exports.
+func defpackage(scope Scope, args ...SExpr) (SExpr, error) {
+	if err := checkArity("defpackage", args, 1, 2); err != nil {
+		return nil, err
+	}
+	name, err := packageName("defpackage", args[0])
+	if err != nil {
+		return nil, err
+	}
+	exports := scope.packages().define(name)
+
+	if len(args) < 2 {
+		return args[0], nil
+	}
+	names, ok := args[1].(List)
+	if !ok {
+		return nil, TypeError{Fn: "defpackage", Arg: 2, Expected: "a list of exported names", Got: args[1]}
+	}
+	for n := range names.Items() {
+		sym, ok := n.(Symbol)
+		if !ok {
+			return nil, TypeError{Fn: "defpackage", Arg: 2, Expected: "a list of exported names", Got: args[1]}
+		}
+		exports[sym.name] = true
+	}
+
+	return args[0], nil
+}
+
+// inPackage switches the current package, defining it if needed: (in-package name)
+func inPackage(scope Scope, args ...SExpr) (SExpr, error) {
+	if err := checkArity("in-package", args, 1, 1); err != nil {
+		return nil, err
+	}
+	name, err := packageName("in-package", args[0])
+	if err != nil {
+		return nil, err
+	}
+	pkgs := scope.packages()
+	pkgs.define(name)
+	pkgs.current = name
+
+	return args[0], nil
+}
+
+// packages returns the packages of the scope creating them if needed
+func (scope Scope) packages() *packages {
+	if scope.session == nil {
+		return newPackages()
+	}
+	if scope.session.packages == nil {
+		scope.session.packages = newPackages()
+	}
+
+	return scope.session.packages
+}
+
+// define adds a package unless it's defined and returns its exports
+func (p *packages) define(name string) map[string]bool {
+	if _, ok := p.exports[name]; !ok {
+		p.exports[name] = map[string]bool{}
+	}
+
+	return p.exports[name]
+}
+
+func packageName(fn string, arg SExpr) (string, error) {
+	sym, ok := arg.(Symbol)
+	if !ok {
+		return "", TypeError{Fn: fn, Arg: 1, Expected: "a package name", Got: arg}
+	}
+	if strings.ContainsRune(sym.name, ':') {
+		return "", errors.New(fmt.Sprintf("%s: package name %s can't contain a colon", fn, sym.name))
+	}
+
+	return sym.name, nil
+}
@@ -0,0 +1,60 @@
+package core
+
+import "strings"
+
+// compile-time interface check
+var _ SExpr = new(String)
+
+// String is a string atom. Unlike a symbol it evaluates to itself
+// and may contain any characters, including spaces and parentheses.
+type String struct {
+	span Span
+	val  string
+}
+
+func NewString(srcName string, line, pos uint, val string) String {
+	return String{
+		span: Span{Start: Position{SrcName: srcName, Line: line, Col: pos}},
+		val:  val,
+	}
+}
+
+// WithSpan returns the string read from the given part of the source
+func (s String) WithSpan(span Span) String {
+	s.span = span
+	return s
+}
+
+// Eval for a String returns the string itself
+func (s String) Eval(scope Scope) (SExpr, error) {
+	if err := scope.checkSize(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Value returns the contents of the string
+func (s String) Value() string {
+	return s.val
+}
+
+// String returns the string as a literal which can be read back
+func (s String) String() string {
+	return `"` + stringEscaper.Replace(s.val) + `"`
+}
+
+func (s String) Pos() Position {
+	return s.span.Start
+}
+
+func (s String) Span() Span {
+	return s.span
+}
+
+var stringEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\t", `\t`,
+)
@@ -1,7 +1,5 @@
 package core
 
-import "fmt"
-
 // compile-time interface check
 var _ SExpr = new(Symbol)
 
@@ -9,32 +7,54 @@ var _ SExpr = new(Symbol)
 // For all intents and purposes of this implementation all atoms are symbols.
 // Even numbers are not supported :)
 type Symbol struct {
-	srcName string
-	line    uint
-	pos     uint
-	name    string
+	span Span
+	name string
 }
 
 func NewSymbol(srcName string, line, pos uint, val string) Symbol {
 	return Symbol{
-		srcName: srcName,
-		line:    line,
-		pos:     pos,
-		name:    val,
+		span: Span{Start: Position{SrcName: srcName, Line: line, Col: pos}},
+		name: val,
 	}
 }
 
+// WithSpan returns the symbol read from the given part of the source
+func (s Symbol) WithSpan(span Span) Symbol {
+	s.span = span
+	return s
+}
+
 // Eval for an Atom returns it's value
 func (s Symbol) Eval(scope Scope) (SExpr, error) {
 	// lookup atom among bounded symbols in scope (that includes built-in functions)
-	if v, ok := scope.SymbolValue(s.name); ok {
+	v, ok, err := scope.lookup(s)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
 		return v, nil
 	}
 
-	loc := location(s.srcName, s.line, s.pos)
-	return nil, fmt.Errorf("%s: unbound symbol %v", loc, s.name)
+	if err := scope.missingCapability(s); err != nil {
+		return nil, err
+	}
+
+	return nil, UnboundSymbolError{Name: s.name, Pos: s.Pos()}
 }
 
 func (s Symbol) String() string {
 	return s.name
 }
+
+// Name returns the name of the symbol
+func (s Symbol) Name() string {
+	return s.name
+}
+
+func (s Symbol) Pos() Position {
+	return s.span.Start
+}
+
+func (s Symbol) Span() Span {
+	return s.span
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// compile-time interface check
+var _ SExpr = new(Unspecified)
+
+// Unspecified is the type of Void
+type Unspecified struct{}
+
+// Void is the result of functions which are called for their side effects, like `print`.
+// It's a proper value, so it may be passed around and stored in lists,
+// but the REPL doesn't print it.
+var Void = Unspecified{}
+
+// IsVoid reports whether e is Void
+func IsVoid(e SExpr) bool {
+	_, ok := e.(Unspecified)
+	return ok
+}
+
+// Eval returns Void itself
+func (v Unspecified) Eval(_ Scope) (SExpr, error) {
+	return v, nil
+}
+
+func (v Unspecified) String() string {
+	return "#<void>"
+}
+
+func (v Unspecified) Pos() Position {
+	return Position{}
+}
+
+func (v Unspecified) Span() Span {
+	return Span{}
+}
+
+// PanicError is returned when a function panics instead of crashing the host
+type PanicError struct {
+	Fn    string
+	Value any
+	// Stack is the Go stack of the goroutine at the moment of the panic
+	Stack []byte
+}
+
+func (e PanicError) Error() string {
+	return fmt.Sprintf("%s: panic: %v", e.Fn, e.Value)
+}
+
+// call is the evaluation boundary: it calls the function with prepared arguments,
+// converts panics to errors and a missing result to Void
+func (fn Fn) call(scope Scope, args []SExpr) (result SExpr, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			result, err = nil, PanicError{Fn: fn.name, Value: v, Stack: debug.Stack()}
+		}
+	}()
+
+	result, err = fn.fn(scope, args...)
+	if result == nil && err == nil {
+		result = Void
+	}
+
+	return result, err
+}
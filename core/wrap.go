@@ -0,0 +1,85 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeFor[error]()
+
+// WrapFunc adapts an arbitrary Go function to a builtin, e.g.
+//
+//	add, err := core.WrapFunc("add", func(a, b int) (int, error) { return a + b, nil })
+//	scope.Bind("add", add)
+//
+// Arguments are evaluated and converted to the parameter types of f
+// following the rules of Unmarshal.
+// f may return at most one value, optionally followed by an error.
+// The value is converted back to an S-expression with Marshal,
+// it counts against the size limits and MaxConses of the scope.
+func WrapFunc(name string, f any) (Fn, error) {
+	fv := reflect.ValueOf(f)
+	if fv.Kind() != reflect.Func {
+		return Fn{}, errors.New(fmt.Sprintf("%s: %T is not a function", name, f))
+	}
+	ft := fv.Type()
+
+	numOut := ft.NumOut()
+	returnsErr := numOut > 0 && ft.Out(numOut-1) == errorType
+	if returnsErr {
+		numOut--
+	}
+	if numOut > 1 {
+		return Fn{}, errors.New(fmt.Sprintf("%s: function must return at most one value and an error, returns %d values", name, numOut))
+	}
+
+	wrapped := NewFn(name, func(scope Scope, args ...SExpr) (SExpr, error) {
+		numIn := ft.NumIn()
+		minArgs, maxArgs := numIn, numIn
+		if ft.IsVariadic() {
+			minArgs, maxArgs = numIn-1, -1
+		}
+		if err := checkArity(name, args, minArgs, maxArgs); err != nil {
+			return nil, err
+		}
+
+		in := make([]reflect.Value, len(args))
+		for i, v := range args {
+			t := ft.In(min(i, numIn-1))
+			if ft.IsVariadic() && i >= numIn-1 {
+				t = t.Elem()
+			}
+			var err error
+			in[i], err = toGo(v, t)
+			if err != nil {
+				return nil, TypeError{Fn: name, Arg: i + 1, Expected: t.String(), Got: v}
+			}
+		}
+
+		out := fv.Call(in)
+		if returnsErr {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			out = out[:len(out)-1]
+		}
+		if len(out) == 0 {
+			return Void, nil
+		}
+
+		result, err := fromGo(out[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: result: %w", name, err)
+		}
+		// the result is allocated on behalf of the evaluated code
+		if err := scope.checkAlloc(result); err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	})
+	wrapped.evalArgs = true
+
+	return wrapped, nil
+}
@@ -1,6 +1,10 @@
 package main_test
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"slices"
 	"strings"
 	"testing"
@@ -58,7 +62,7 @@ func TestEval(t *testing.T) {
 		},
 		{
 			input:          "(atom)",
-			expectedErrMsg: "atom: expects 1 argument, 0 given",
+			expectedErrMsg: "atom: expects 1 argument, got 0",
 		},
 		// eq
 		{
@@ -130,7 +134,7 @@ func TestEval(t *testing.T) {
 		},
 		{
 			input:          "(cons 'a 'b)",
-			expectedErrMsg: "cons: 2nd argument must be a list",
+			expectedErrMsg: "cons: argument #2 must be a list",
 		},
 		{
 			input:          "(cons '1)",
@@ -151,13 +155,30 @@ func TestEval(t *testing.T) {
 		},
 		{
 			input:          "(cond cond)",
-			expectedErrMsg: "cond: argument #1 is not a list",
+			expectedErrMsg: "cond: argument #1 must be a list",
 		},
 
 		{
 			input:    "(cond)",
 			expected: "()",
 		},
+		// strings
+		{
+			input:    `"a (b) \"c\""`,
+			expected: `"a (b) \"c\""`,
+		},
+		{
+			input:    `(atom "a")`,
+			expected: "t",
+		},
+		{
+			input:    `(eq "a" "a")`,
+			expected: "t",
+		},
+		{
+			input:    `(eq "a" 'a)`,
+			expected: "()",
+		},
 		{
 			input:    "(cond ((eq 'a 'b) 'first) ((atom 'a) 'second))",
 			expected: "second",
@@ -197,3 +218,770 @@ func TestLambda(t *testing.T) {
 
 	assert.Equal(t, expected, result.String())
 }
+
+func TestLimits(t *testing.T) {
+	const defs = `
+(defun loop (x) (loop x))
+(defun grow (x) (grow (cons 'a x)))
+(defun chatter (x) (cond ((print x) x) ('t (chatter x))))
+`
+	cases := []struct {
+		input         string
+		limits        core.Limits
+		expectedLimit string
+	}{
+		{
+			input:         "(loop 'a)",
+			limits:        core.Limits{MaxSteps: 1000},
+			expectedLimit: "MaxSteps",
+		},
+		{
+			input:         "(grow '())",
+			limits:        core.Limits{MaxConses: 100},
+			expectedLimit: "MaxConses",
+		},
+		{
+			input:         "(grow '())",
+			limits:        core.Limits{MaxListLen: 5},
+			expectedLimit: "MaxListLen",
+		},
+		{
+			input:         "'abcdef",
+			limits:        core.Limits{MaxStringLen: 5},
+			expectedLimit: "MaxStringLen",
+		},
+		{
+			input:         `"abcdef"`,
+			limits:        core.Limits{MaxStringLen: 5},
+			expectedLimit: "MaxStringLen",
+		},
+		{
+			input:         `(cons "abcdef" '())`,
+			limits:        core.Limits{MaxStringLen: 5},
+			expectedLimit: "MaxStringLen",
+		},
+		{
+			input:         "(chatter 'a)",
+			limits:        core.Limits{MaxOutputBytes: 10},
+			expectedLimit: "MaxOutputBytes",
+		},
+	}
+
+	for tc := range slices.Values(cases) {
+		t.Run(tc.input, func(t *testing.T) {
+			scope := core.BuiltinScope().WithPorts(nil, io.Discard, nil)
+			exprs, err := parser.Parse("test", 0, strings.NewReader(defs))
+			require.NoError(t, err)
+			for _, e := range exprs {
+				_, err := e.Eval(scope)
+				require.NoError(t, err)
+			}
+
+			exprs, err = parser.Parse("test", 0, strings.NewReader(tc.input))
+			require.NoError(t, err)
+			_, err = exprs[0].Eval(scope.WithLimits(tc.limits))
+
+			var limitErr core.LimitError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, tc.expectedLimit, limitErr.Limit)
+		})
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	scope := core.NewScope(core.Pure)
+	scope.Deny("cons")
+
+	cases := []struct {
+		input              string
+		expected           string
+		expectedCapability core.Capability
+		expectedErrMsg     string
+	}{
+		{
+			input:    "(car '(a b))",
+			expected: "a",
+		},
+		{
+			input:              "(print 'a)",
+			expectedCapability: core.Printing,
+			expectedErrMsg:     "print requires capability printing",
+		},
+		{
+			input:          "(cons 'a '())",
+			expectedErrMsg: "cons is denied",
+		},
+		{
+			// a parameter shadows a withheld builtin without replacing it
+			input:    "((lambda (print) print) 'a)",
+			expected: "a",
+		},
+		{
+			input:              "(print 'b)",
+			expectedCapability: core.Printing,
+			expectedErrMsg:     "print requires capability printing",
+		},
+	}
+
+	for tc := range slices.Values(cases) {
+		t.Run(tc.input, func(t *testing.T) {
+			exprs, err := parser.Parse("test", 0, strings.NewReader(tc.input))
+			require.NoError(t, err)
+			result, err := exprs[0].Eval(scope)
+			if tc.expectedErrMsg == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, result.String())
+				return
+			}
+			var capErr core.CapabilityError
+			require.ErrorAs(t, err, &capErr)
+			assert.Equal(t, tc.expectedCapability, capErr.Capability)
+			assert.ErrorContains(t, err, tc.expectedErrMsg)
+		})
+	}
+
+	// denying a builtin in a layer denies it in the root scope too
+	layer := scope.NewLayer()
+	layer.Bind("cdr", core.True)
+	layer.Deny("car", "cdr")
+	_, err := core.NewSymbol("test", 1, 1, "car").Eval(scope)
+	assert.ErrorContains(t, err, "car is denied")
+	// but definitions of the same names in layers are kept
+	v, ok := layer.SymbolValue("cdr")
+	assert.True(t, ok)
+	assert.Equal(t, core.True, v)
+}
+
+func TestWrapFunc(t *testing.T) {
+	scope := core.BuiltinScope()
+	funcs := map[string]any{
+		"add": func(a, b int) (int, error) { return a + b, nil },
+		"div": func(a, b float64) (float64, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a / b, nil
+		},
+		"join": func(sep string, parts ...string) string { return strings.Join(parts, sep) },
+		"rev": func(l []core.SExpr) []core.SExpr {
+			slices.Reverse(l)
+			return l
+		},
+		"not": func(b bool) bool { return !b },
+	}
+	for name, f := range funcs {
+		fn, err := core.WrapFunc(name, f)
+		require.NoError(t, err)
+		scope.Bind(name, fn)
+	}
+
+	cases := []struct {
+		input          string
+		expected       string
+		expectedErrMsg string
+	}{
+		{
+			input:    "(add '1 '2)",
+			expected: "3",
+		},
+		{
+			input:    "(div '1 '4)",
+			expected: "0.25",
+		},
+		{
+			input:    "(join '- 'a \"b\" 'c)",
+			expected: `"a-b-c"`,
+		},
+		{
+			input:    "(rev '(a (b) c))",
+			expected: "(c (b) a)",
+		},
+		{
+			input:    "(not (eq 'a 'b))",
+			expected: "t",
+		},
+		{
+			input:          "(add '1)",
+			expectedErrMsg: "add: expects 2 arguments, got 1",
+		},
+		{
+			input:          "(add 'x '1)",
+			expectedErrMsg: "add: argument #1 must be int, got x",
+		},
+		{
+			input:          "(div '1 '0)",
+			expectedErrMsg: "div: division by zero",
+		},
+		{
+			input:          "(join)",
+			expectedErrMsg: "join: expects at least 1 argument, got 0",
+		},
+	}
+
+	for tc := range slices.Values(cases) {
+		t.Run(tc.input, func(t *testing.T) {
+			exprs, err := parser.Parse("test", 0, strings.NewReader(tc.input))
+			require.NoError(t, err)
+			result, err := exprs[0].Eval(scope)
+			if tc.expectedErrMsg != "" {
+				require.ErrorContains(t, err, tc.expectedErrMsg)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, result.String())
+			}
+		})
+	}
+
+	_, err := core.WrapFunc("bad", "not a function")
+	require.Error(t, err)
+
+	// results count against the limits of the calling code
+	words, err := core.WrapFunc("words", func(s string) []string { return strings.Fields(s) })
+	require.NoError(t, err)
+	scope.Bind("words", words)
+	for _, tc := range []struct {
+		input  string
+		limits core.Limits
+	}{
+		{`(words "a b c d")`, core.Limits{MaxConses: 3}},
+		{`(words "a b c d")`, core.Limits{MaxListLen: 3}},
+		{`(words "a bcdef")`, core.Limits{MaxStringLen: 3}},
+		{`(cons (words "a b") '())`, core.Limits{MaxConses: 2}},
+	} {
+		exprs, err := parser.Parse("test", 0, strings.NewReader(tc.input))
+		require.NoError(t, err)
+		_, err = exprs[0].Eval(scope.WithLimits(tc.limits))
+		var limitErr core.LimitError
+		assert.ErrorAs(t, err, &limitErr, tc.input)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	type server struct {
+		Host    string
+		Port    int
+		Tags    []string `sexpr:"tags,omitempty"`
+		Debug   bool     `sexpr:"debug-mode"`
+		Ratio   float64
+		Limits  map[string]uint
+		Backup  *server
+		Ignored string `sexpr:"-"`
+	}
+	in := server{
+		Host:   "example.com",
+		Port:   8080,
+		Tags:   []string{"a b", "c"},
+		Debug:  true,
+		Ratio:  0.5,
+		Limits: map[string]uint{"conns": 10, "bytes": 1024},
+		Backup: &server{Host: "backup.example.com"},
+	}
+
+	e, err := core.Marshal(in)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`((host "example.com") (port 8080) (tags ("a b" "c")) (debug-mode t) (ratio 0.5) `+
+			`(limits (("bytes" 1024) ("conns" 10))) (backup ((host "backup.example.com") (port 0) (debug-mode ()) (ratio 0) (limits ()) (backup nil))))`,
+		e.String())
+
+	var out server
+	require.NoError(t, core.Unmarshal(e, &out))
+	assert.Equal(t, in, out)
+
+	// configuration files may use property lists too
+	exprs, err := parser.Parse("test", 0, strings.NewReader(`(:host "localhost" :port 80 :tags (web))`))
+	require.NoError(t, err)
+	var cfg server
+	require.NoError(t, core.Unmarshal(exprs[0], &cfg))
+	assert.Equal(t, server{Host: "localhost", Port: 80, Tags: []string{"web"}}, cfg)
+
+	// string keys read back whatever they contain
+	keys := map[string]int{"a b": 1, "(x)": 2, `q"uote`: 3, "1st": 4, "": 5}
+	e, err = core.Marshal(keys)
+	require.NoError(t, err)
+	exprs, err = parser.Parse("test", 0, strings.NewReader(e.String()))
+	require.NoError(t, err)
+	var keysOut map[string]int
+	require.NoError(t, core.Unmarshal(exprs[0], &keysOut))
+	assert.Equal(t, keys, keysOut)
+
+	// empty slices and nil pointers are told apart
+	type opt struct {
+		Tags *[]string
+		Next *opt
+	}
+	for _, in := range []opt{{}, {Tags: &[]string{}, Next: &opt{}}} {
+		e, err := core.Marshal(in)
+		require.NoError(t, err)
+		exprs, err := parser.Parse("test", 0, strings.NewReader(e.String()))
+		require.NoError(t, err)
+		var out opt
+		require.NoError(t, core.Unmarshal(exprs[0], &out))
+		assert.Equal(t, in, out, e.String())
+	}
+	var empty []string
+	require.NoError(t, core.Unmarshal(core.FromSlice(nil), &empty))
+	assert.Equal(t, []string{}, empty)
+
+	var n int
+	require.ErrorContains(t, core.Unmarshal(core.NewSymbol("", 0, 0, "x"), &n), "cannot convert x to int")
+	require.Error(t, core.Unmarshal(core.NewSymbol("", 0, 0, "x"), n))
+}
+
+func TestAccessors(t *testing.T) {
+	exprs, err := parser.Parse("test", 0, strings.NewReader(`(foo "bar" (baz))`))
+	require.NoError(t, err)
+	l, ok := exprs[0].(core.List)
+	require.True(t, ok)
+
+	assert.Equal(t, core.Position{SrcName: "test", Line: 1, Col: 1}, l.Pos())
+	assert.Equal(t, 3, l.Len())
+
+	first, ok := l.Nth(0)
+	require.True(t, ok)
+	require.True(t, core.IsSymbol(first))
+	assert.Equal(t, "foo", first.(core.Symbol).Name())
+	assert.Equal(t, core.Position{SrcName: "test", Line: 1, Col: 2, Offset: 1}, first.Pos())
+
+	second, ok := l.Nth(1)
+	require.True(t, ok)
+	assert.True(t, core.IsString(second))
+	assert.True(t, core.IsAtom(second))
+
+	third, ok := l.Nth(2)
+	require.True(t, ok)
+	assert.True(t, core.IsList(third))
+	assert.False(t, core.IsAtom(third))
+
+	_, ok = l.Nth(3)
+	assert.False(t, ok)
+
+	assert.Equal(t, l.String(), core.FromSlice(l.ToSlice()).String())
+
+	defun, ok := core.BuiltinScope().SymbolValue("defun")
+	require.True(t, ok)
+	require.True(t, core.IsFn(defun))
+	assert.Equal(t, "defun", defun.(core.Fn).Name())
+}
+
+func TestApply(t *testing.T) {
+	scope := core.BuiltinScope()
+	list := core.FromSlice([]core.SExpr{core.NewSymbol("", 0, 0, "a")})
+
+	// the arguments are not evaluated again
+	first, _ := scope.SymbolValue("car")
+	result, err := first.(core.Fn).Apply(scope, list)
+	require.NoError(t, err)
+	assert.Equal(t, "a", result.String())
+
+	fn, err := parser.Parse("test", 0, strings.NewReader("(lambda (x) (cdr x))"))
+	require.NoError(t, err)
+	rest, err := fn[0].Eval(scope)
+	require.NoError(t, err)
+	result, err = rest.(core.Fn).Apply(scope, list)
+	require.NoError(t, err)
+	assert.Equal(t, "()", result.String())
+}
+
+func TestLoadAtomic(t *testing.T) {
+	scope := core.BuiltinScope()
+	load := func(src string) ([]string, error) {
+		names := []string{}
+		forms := parser.NewReader("test", 0, strings.NewReader(src)).All()
+		err := scope.LoadAtomic("test", forms, func(form, _ core.SExpr) {
+			if name, ok := core.DefinedName(form); ok {
+				names = append(names, name)
+			}
+		})
+		return names, err
+	}
+
+	_, err := load("(defun f () 'a)\n(car x)\n(defun g () (f))\n(cdr y)")
+	var loadErr *core.LoadError
+	require.ErrorAs(t, err, &loadErr)
+	assert.Len(t, loadErr.Errs, 2)
+	_, ok := scope.SymbolValue("f")
+	assert.False(t, ok, "nothing is kept after a failure")
+
+	names, err := load("(defun f () 'a)\n(label g (lambda () (f)))\n'h")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"f", "g"}, names)
+	_, ok = scope.SymbolValue("g")
+	assert.True(t, ok)
+}
+
+func TestPackages(t *testing.T) {
+	scope := core.BuiltinScope()
+	eval := func(src string) (core.SExpr, error) {
+		t.Helper()
+		exprs, err := parser.Parse("test", 0, strings.NewReader(src))
+		require.NoError(t, err)
+		var result core.SExpr
+		for _, e := range exprs {
+			result, err = e.Eval(scope)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+
+	result, err := eval(`
+		(defpackage list (second))
+		(in-package list)
+		(defun rest (x) (cdr x))
+		(defun second (x) (car (rest x)))
+		(in-package user)
+		(defun rest (x) 'shadowed)`)
+	require.NoError(t, err)
+	assert.Equal(t, "function rest @ test:7:10", result.String())
+	assert.Equal(t, core.UserPackage, scope.Package())
+
+	// the function sees the names of its package wherever it's called from
+	result, err = eval("(list:second '(a b))")
+	require.NoError(t, err)
+	assert.Equal(t, "b", result.String())
+	result, err = eval("(list::rest '(a b))")
+	require.NoError(t, err)
+	assert.Equal(t, "(b)", result.String())
+	result, err = eval("list:second")
+	require.NoError(t, err)
+	assert.Equal(t, "function list:second @ test:5:10", result.String())
+
+	var pkgErr core.PackageError
+	_, err = eval("(list:rest '(a b))")
+	require.ErrorAs(t, err, &pkgErr)
+	assert.EqualError(t, pkgErr, "test:1:2: list:rest: rest is not exported from list, use list::rest")
+	_, err = eval("(set:union '(a) '(b))")
+	require.ErrorContains(t, err, "set:union: package set is not defined")
+
+	// builtins are visible in all packages
+	result, err = eval("(in-package list) (rest '(a b))")
+	require.NoError(t, err)
+	assert.Equal(t, "(b)", result.String())
+	assert.Equal(t, "list", scope.Package())
+
+	// parameters shadow the definitions of the package
+	result, err = eval("(defun x () 'fn) (defun f (x) x) (f 'arg)")
+	require.NoError(t, err)
+	assert.Equal(t, "arg", result.String())
+}
+
+func TestNames(t *testing.T) {
+	scope := core.NewScope(core.Pure).NewLayer()
+	scope.Bind("zeta", core.True)
+	scope.Bind("car", core.True) // shadows the builtin
+
+	names := scope.Names()
+	assert.True(t, slices.IsSorted(names))
+	assert.Contains(t, names, "zeta")
+	assert.Contains(t, names, "cons")
+	assert.Equal(t, slices.Compact(slices.Clone(names)), names)
+	assert.NotContains(t, names, "print")
+}
+
+func TestPorts(t *testing.T) {
+	cases := []struct {
+		input          string
+		stdin          string
+		expected       string
+		expectedOutput string
+	}{
+		{
+			input:          `(print "a" 'b)`,
+			expectedOutput: "\"a\" b\n",
+		},
+		{
+			input:          `(write "a")`,
+			expectedOutput: `"a"`,
+		},
+		{
+			input:          `(display "a")`,
+			expectedOutput: "a",
+		},
+		{
+			input:          `(newline)`,
+			expectedOutput: "\n",
+		},
+		{
+			input:          `(display 'b (current-error-port))`,
+			expectedOutput: "",
+		},
+		{
+			input:    `(with-output-to-string (display "a") (newline) (write '(b "c")))`,
+			expected: `"a\n(b \"c\")"`,
+		},
+		{
+			input:    `(read-line)`,
+			stdin:    "first line\nsecond line\n",
+			expected: `"first line"`,
+		},
+		{
+			input:    `(read-line (current-input-port))`,
+			expected: "()",
+		},
+	}
+
+	for tc := range slices.Values(cases) {
+		t.Run(tc.input, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			scope := core.BuiltinScope().WithPorts(strings.NewReader(tc.stdin), &out, &errOut)
+
+			exprs, err := parser.Parse("test", 0, strings.NewReader(tc.input))
+			require.NoError(t, err)
+			result, err := exprs[0].Eval(scope)
+			require.NoError(t, err)
+			if tc.expected != "" {
+				assert.Equal(t, tc.expected, result.String())
+			}
+			assert.Equal(t, tc.expectedOutput, out.String())
+		})
+	}
+}
+
+type recorder struct {
+	core.NopObserver
+	events []string
+}
+
+func (r *recorder) Enter(call core.Call) {
+	r.events = append(r.events, fmt.Sprintf("%d enter %s %v", call.Depth, call.Fn.Name(), core.FromSlice(call.Args)))
+}
+
+func (r *recorder) Exit(call core.Call, result core.SExpr) {
+	r.events = append(r.events, fmt.Sprintf("%d exit %s %v", call.Depth, call.Fn.Name(), result))
+}
+
+func (r *recorder) Error(call core.Call, err error) {
+	r.events = append(r.events, fmt.Sprintf("%d error %s", call.Depth, call.Fn.Name()))
+}
+
+func (r *recorder) Bind(name string, value core.SExpr) {
+	r.events = append(r.events, fmt.Sprintf("bind %s %v", name, value))
+}
+
+func TestObserver(t *testing.T) {
+	rec := &recorder{}
+	scope := core.BuiltinScope().WithObserver(rec)
+
+	exprs, err := parser.Parse("test", 0, strings.NewReader("((lambda (x) (car x)) '(a b))\n(car 'a)"))
+	require.NoError(t, err)
+	result, err := exprs[0].Eval(scope)
+	require.NoError(t, err)
+	assert.Equal(t, "a", result.String())
+	_, err = exprs[1].Eval(scope)
+	require.Error(t, err)
+
+	assert.Equal(t, []string{
+		"0 enter lambda ((x) (car x))",
+		"0 exit lambda function @ test:1:10",
+		"0 enter quote ((a b))",
+		"0 exit quote (a b)",
+		"0 enter  ((a b))",
+		"bind x (a b)",
+		"1 enter car (x)",
+		"1 exit car a",
+		"0 exit  a",
+		"0 enter car ((quote a))",
+		"1 enter quote (a)",
+		"1 exit quote a",
+		"0 error car",
+	}, rec.events)
+}
+
+func TestTrace(t *testing.T) {
+	const input = `
+(defun app (x y) (cond ((eq x '()) y) ('t (cons (car x) (app (cdr x) y)))))
+(trace app)
+(app '(a b) '(c))
+(untrace app)
+(app '(a) '())
+`
+	const expected = `0: (app (a b) (c))
+  1: (app (b) (c))
+    2: (app () (c))
+    2: app returned (c)
+  1: app returned (b c)
+0: app returned (a b c)
+`
+	var out bytes.Buffer
+	scope := core.BuiltinScope().WithPorts(nil, &out, nil)
+	exprs, err := parser.Parse("test", 0, strings.NewReader(input))
+	require.NoError(t, err)
+	for _, e := range exprs {
+		_, err := e.Eval(scope)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, expected, out.String())
+
+	// a trace that fails to print doesn't shift the following ones
+	exprs, err = parser.Parse("test", 0, strings.NewReader("(trace app)\n(app '(a b) '(c))"))
+	require.NoError(t, err)
+	_, err = exprs[0].Eval(scope)
+	require.NoError(t, err)
+	_, err = exprs[1].Eval(scope.WithLimits(core.Limits{MaxOutputBytes: 20}))
+	var limitErr core.LimitError
+	require.ErrorAs(t, err, &limitErr)
+	out.Reset()
+	_, err = exprs[1].Eval(scope)
+	require.NoError(t, err)
+	assert.Equal(t, expected, out.String())
+}
+
+func TestBacktrace(t *testing.T) {
+	const input = `
+(defun second (x) (car (cdr x)))
+(defun f (x) (cond ('t (second x))))
+(f 'a)
+`
+	exprs, err := parser.Parse("test", 0, strings.NewReader(input))
+	require.NoError(t, err)
+	scope := core.BuiltinScope()
+	for _, e := range exprs[:2] {
+		_, err := e.Eval(scope)
+		require.NoError(t, err)
+	}
+	_, err = exprs[2].Eval(scope)
+
+	var evalErr *core.EvalError
+	require.ErrorAs(t, err, &evalErr)
+	assert.Equal(t, "test:2:24: evaluation error: cdr: argument must be a list, got a", err.Error())
+
+	fns := []string{}
+	for _, f := range evalErr.Frames {
+		fns = append(fns, f.Fn)
+	}
+	assert.Equal(t, []string{"cdr", "car", "second", "cond", "f"}, fns)
+	assert.Equal(t, "(second x)", evalErr.Frames[2].Form.String())
+	assert.Equal(t, core.Position{SrcName: "test", Line: 4, Col: 1, Offset: 71}, evalErr.Frames[4].Pos)
+
+	assert.Equal(t, `  in cdr @ test:2:24
+  in car @ test:2:19
+  in second @ test:3:24
+  in cond @ test:3:14
+  in f @ test:4:1
+`, evalErr.Backtrace())
+	assert.Contains(t, fmt.Sprintf("%+v", err), "  in second @ test:3:24\n    (second x)\n")
+}
+
+func TestErrorTypes(t *testing.T) {
+	cases := []struct {
+		input string
+		check func(t *testing.T, err error)
+	}{
+		{
+			input: "(car x)",
+			check: func(t *testing.T, err error) {
+				var e core.UnboundSymbolError
+				require.ErrorAs(t, err, &e)
+				assert.Equal(t, "x", e.Name)
+				assert.Equal(t, core.Position{SrcName: "test", Line: 1, Col: 6, Offset: 5}, e.Pos)
+			},
+		},
+		{
+			input: "(eq 'a)",
+			check: func(t *testing.T, err error) {
+				var e core.ArityError
+				require.ErrorAs(t, err, &e)
+				assert.Equal(t, core.ArityError{Fn: "eq", Min: 2, Max: 2, Got: 1}, e)
+			},
+		},
+		{
+			input: "((lambda (x y) x) 'a)",
+			check: func(t *testing.T, err error) {
+				var e core.ArityError
+				require.ErrorAs(t, err, &e)
+				assert.Equal(t, core.ArityError{Fn: "lambda", Min: 2, Max: 2, Got: 1}, e)
+			},
+		},
+		{
+			input: "(cdr 'a)",
+			check: func(t *testing.T, err error) {
+				var e core.TypeError
+				require.ErrorAs(t, err, &e)
+				assert.Equal(t, "cdr", e.Fn)
+				assert.Equal(t, "a list", e.Expected)
+				assert.Equal(t, "a", e.Got.String())
+			},
+		},
+		{
+			input: "('a 'b)",
+			check: func(t *testing.T, err error) {
+				var e core.NotCallableError
+				require.ErrorAs(t, err, &e)
+				assert.Equal(t, "a", e.Value.String())
+				assert.Equal(t, core.Position{SrcName: "test", Line: 1, Col: 1}, e.Pos)
+			},
+		},
+	}
+
+	for tc := range slices.Values(cases) {
+		t.Run(tc.input, func(t *testing.T) {
+			exprs, err := parser.Parse("test", 0, strings.NewReader(tc.input))
+			require.NoError(t, err)
+			_, err = exprs[0].Eval(core.BuiltinScope())
+			tc.check(t, err)
+		})
+	}
+}
+
+func TestVoid(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "(print 'a)",
+			expected: "#<void>",
+		},
+		{
+			input:    "(cons (print 'a) '())",
+			expected: "(#<void>)",
+		},
+		{
+			input:    "(atom (print 'a))",
+			expected: "()",
+		},
+		{
+			input:    "(car (cons (newline) '(b)))",
+			expected: "#<void>",
+		},
+	}
+
+	for tc := range slices.Values(cases) {
+		t.Run(tc.input, func(t *testing.T) {
+			scope := core.BuiltinScope().WithPorts(nil, io.Discard, nil)
+			exprs, err := parser.Parse("test", 0, strings.NewReader(tc.input))
+			require.NoError(t, err)
+			result, err := exprs[0].Eval(scope)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result.String())
+		})
+	}
+}
+
+func TestPanicBoundary(t *testing.T) {
+	scope := core.BuiltinScope()
+	scope.Bind("boom", core.NewFn("boom", func(scope core.Scope, args ...core.SExpr) (core.SExpr, error) {
+		var l []core.SExpr
+		return l[len(args)], nil
+	}))
+	scope.Bind("nothing", core.NewFn("nothing", func(scope core.Scope, args ...core.SExpr) (core.SExpr, error) {
+		return nil, nil
+	}))
+
+	exprs, err := parser.Parse("test", 0, strings.NewReader("(car (boom))\n(nothing)"))
+	require.NoError(t, err)
+
+	_, err = exprs[0].Eval(scope)
+	var panicErr core.PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "boom", panicErr.Fn)
+	assert.Contains(t, string(panicErr.Stack), "runtime/debug.Stack")
+	assert.ErrorContains(t, err, "boom: panic: runtime error: index out of range")
+
+	result, err := exprs[1].Eval(scope)
+	require.NoError(t, err)
+	assert.True(t, core.IsVoid(result))
+}
@@ -0,0 +1,366 @@
+// Package diag renders errors together with the source code they point to:
+//
+//	<repl>:1:2: evaluation error: unbound symbol cadrr
+//	  |
+//	1 | (cadrr '(a b))
+//	  |  ^^^^^
+//	  = did you mean `cadr`?
+package diag
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/reflechant/minimal-lisp/core"
+	"github.com/reflechant/minimal-lisp/lexer"
+	"github.com/reflechant/minimal-lisp/parser"
+)
+
+// ANSI escape sequences used when colors are enabled
+const (
+	bold  = "\x1b[1m"
+	red   = "\x1b[31m"
+	blue  = "\x1b[34m"
+	cyan  = "\x1b[36m"
+	reset = "\x1b[0m"
+)
+
+// Sources maps source names (file paths, "<repl>", etc.) to their text
+type Sources map[string]string
+
+// Line returns the given line (counting from 1) of the named source
+func (s Sources) Line(srcName string, line uint) (string, bool) {
+	text, ok := s[srcName]
+	if !ok || line == 0 {
+		return "", false
+	}
+	lines := strings.Split(text, "\n")
+	if int(line) > len(lines) {
+		return "", false
+	}
+
+	return strings.TrimSuffix(lines[line-1], "\r"), true
+}
+
+// maxLineLen is the number of bytes of a line a Window keeps
+const maxLineLen = 1024
+
+// Window is an io.Writer keeping only the last lines of the text written to it,
+// so that errors in long streamed input can be shown with their lines
+// without keeping all of it in memory. Lines are truncated to 1KiB.
+type Window struct {
+	max int
+	// first is the number of lines[0] counting from 1
+	first uint
+	lines []string
+	// last is the line being written
+	last strings.Builder
+}
+
+// NewWindow returns a window keeping the last maxLines lines
+func NewWindow(maxLines int) *Window {
+	return &Window{max: maxLines, first: 1}
+}
+
+func (w *Window) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.appendToLast(p)
+			break
+		}
+		w.appendToLast(p[:i])
+		w.lines = append(w.lines, w.last.String())
+		w.last.Reset()
+		if len(w.lines) > w.max {
+			w.lines = w.lines[1:]
+			w.first++
+		}
+		p = p[i+1:]
+	}
+
+	return n, nil
+}
+
+func (w *Window) appendToLast(p []byte) {
+	if room := maxLineLen - w.last.Len(); room > 0 {
+		w.last.Write(p[:min(len(p), room)])
+	}
+}
+
+// Line returns the given line (counting from 1) if it's still kept
+func (w *Window) Line(line uint) (string, bool) {
+	if line < w.first {
+		return "", false
+	}
+	var text string
+	switch i := int(line - w.first); {
+	case i < len(w.lines):
+		text = w.lines[i]
+	case i == len(w.lines):
+		text = w.last.String()
+	default:
+		return "", false
+	}
+
+	// a truncated line may end in the middle of a rune
+	return strings.ToValidUTF8(strings.TrimSuffix(text, "\r"), ""), true
+}
+
+// Windows maps source names to the windows of their last lines
+type Windows map[string]*Window
+
+// Line returns the given line (counting from 1) of the named source
+// if its window still keeps it
+func (ws Windows) Line(srcName string, line uint) (string, bool) {
+	w, ok := ws[srcName]
+	if !ok {
+		return "", false
+	}
+
+	return w.Line(line)
+}
+
+// Renderer formats errors for humans
+type Renderer struct {
+	// Color enables ANSI colors
+	Color bool
+	// Source returns a line (counting from 1) of the named source,
+	// no snippet is printed if it is nil or the line is unknown
+	Source func(srcName string, line uint) (string, bool)
+	// Names returns the bound names suggested in place of unbound symbols
+	Names func() []string
+}
+
+// Render returns the error message followed by the offending source line
+// with the erroneous part underlined, a suggestion for a misspelled symbol
+// and the backtrace of nested calls. The result ends with a newline.
+// The failures listed by a core.LoadError and errors joined with errors.Join
+// are rendered one after another.
+func (r Renderer) Render(err error) string {
+	var b strings.Builder
+
+	// errors joined with errors.Join are rendered one after another
+	if _, ok := err.(*core.LoadError); !ok {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				b.WriteString(r.Render(e))
+			}
+			return b.String()
+		}
+	}
+
+	// every failure of a load is rendered separately
+	var loadErr *core.LoadError
+	if errors.As(err, &loadErr) {
+		header, _, _ := strings.Cut(err.Error(), "\n")
+		b.WriteString(r.paint(bold+red, header))
+		b.WriteByte('\n')
+		for _, e := range loadErr.Errs {
+			b.WriteString(r.Render(e))
+		}
+		return b.String()
+	}
+
+	b.WriteString(r.paint(bold+red, err.Error()))
+	b.WriteByte('\n')
+
+	if span, ok := locate(err); ok && r.Source != nil {
+		if line, ok := r.Source(span.Start.SrcName, span.Start.Line); ok {
+			r.snippet(&b, line, span)
+		}
+	}
+
+	var unbound core.UnboundSymbolError
+	if errors.As(err, &unbound) && r.Names != nil {
+		if name, ok := Suggest(unbound.Name, r.Names()); ok {
+			fmt.Fprintf(&b, "  = did you mean `%s`?\n", r.paint(cyan, name))
+		}
+	}
+
+	var evalErr *core.EvalError
+	if errors.As(err, &evalErr) && len(evalErr.Frames) > 1 {
+		b.WriteString(evalErr.Backtrace())
+	}
+
+	return b.String()
+}
+
+// snippet prints the source line with carets under the span
+//
+//	  |
+//	3 | (car x y)
+//	  | ^^^^^^^^^
+func (r Renderer) snippet(b *strings.Builder, line string, span core.Span) {
+	num := fmt.Sprint(span.Start.Line)
+	gutter := strings.Repeat(" ", len(num))
+
+	// keep tabs so that the carets line up with the source
+	var indent strings.Builder
+	var col uint = 1
+	for _, c := range line {
+		if col >= span.Start.Col {
+			break
+		}
+		if c == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+		col++
+	}
+
+	width := 1
+	lineLen := utf8.RuneCountInString(line)
+	switch {
+	case span.End.Line == span.Start.Line && span.End.Col > span.Start.Col:
+		width = int(span.End.Col - span.Start.Col)
+	case lineLen >= int(span.Start.Col):
+		// the span continues on the next lines, underline the rest of this one
+		width = lineLen - int(span.Start.Col) + 1
+	}
+
+	fmt.Fprintf(b, "%s %s\n", gutter, r.paint(blue, "|"))
+	fmt.Fprintf(b, "%s %s\n", r.paint(blue, num+" |"), line)
+	fmt.Fprintf(b, "%s %s%s\n", gutter, r.paint(blue, "|"), " "+indent.String()+r.paint(bold+red, strings.Repeat("^", width)))
+}
+
+func (r Renderer) paint(color, s string) string {
+	if !r.Color {
+		return s
+	}
+	return color + s + reset
+}
+
+// locate returns the part of the source the error points to
+func locate(err error) (core.Span, bool) {
+	var (
+		unbound     core.UnboundSymbolError
+		denied      core.CapabilityError
+		notCallable core.NotCallableError
+		evalErr     *core.EvalError
+		parseErr    parser.ParseError
+		lexErr      lexer.LexError
+	)
+
+	var span core.Span
+	switch {
+	case errors.As(err, &unbound):
+		span = nameSpan(unbound.Pos, unbound.Name)
+	case errors.As(err, &denied):
+		span = nameSpan(denied.Pos, denied.Name)
+	case errors.As(err, &evalErr) && len(evalErr.Frames) > 0:
+		form := evalErr.Frames[0].Form
+		span = form.Span()
+		// point to the thing which is not a function
+		if errors.As(err, &notCallable) && !form.IsEmpty() {
+			span = form.First().Span()
+		}
+	case errors.As(err, &parseErr):
+		span = nameSpan(parseErr.Pos, parseErr.Token)
+	case errors.As(err, &lexErr):
+		pos := core.Position{SrcName: lexErr.SrcName, Line: lexErr.Line, Col: lexErr.Col, Offset: lexErr.Offset}
+		span = core.Span{Start: pos}
+	default:
+		return core.Span{}, false
+	}
+
+	// dynamically constructed code has no source
+	return span, span.Start.Line > 0
+}
+
+// nameSpan returns the span of a name written at pos
+func nameSpan(pos core.Position, name string) core.Span {
+	end := pos
+	end.Col += uint(utf8.RuneCountInString(name))
+	end.Offset += uint(len(name))
+
+	return core.Span{Start: pos, End: end}
+}
+
+// Suggest returns the name closest to the misspelled one,
+// names which differ too much are not suggested.
+// Of equally close names the one sharing the longest prefix wins.
+func Suggest(misspelled string, names []string) (string, bool) {
+	best, bestDist, bestPrefix := "", 0, 0
+	// allow one typo per 3 characters
+	maxDist := max(1, utf8.RuneCountInString(misspelled)/3)
+
+	for _, name := range names {
+		if name == misspelled {
+			continue
+		}
+		d := distance(misspelled, name)
+		if d > maxDist {
+			continue
+		}
+		prefix := commonPrefix(misspelled, name)
+		if best == "" || d < bestDist || (d == bestDist && prefix > bestPrefix) {
+			best, bestDist, bestPrefix = name, d, prefix
+		}
+	}
+
+	return best, best != ""
+}
+
+// commonPrefix returns the number of leading bytes a and b share
+func commonPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// distance is the number of typos (inserted, deleted, replaced or swapped
+// adjacent runes) which turn a into b
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+// ColorEnabled reports whether colors should be used for writing to w:
+// it must be a terminal and the NO_COLOR environment variable must not be set
+func ColorEnabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,131 @@
+package diag
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/reflechant/minimal-lisp/core"
+	"github.com/reflechant/minimal-lisp/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eval evaluates src and returns the error rendered without colors
+func eval(t *testing.T, src string) string {
+	t.Helper()
+
+	sources := Sources{"test": src}
+	scope := core.BuiltinScope()
+	r := Renderer{Source: sources.Line, Names: scope.Names}
+
+	exprs, err := parser.Parse("test", 0, strings.NewReader(src))
+	if err != nil {
+		return r.Render(err)
+	}
+	for _, e := range exprs {
+		if _, err := e.Eval(scope); err != nil {
+			return r.Render(err)
+		}
+	}
+	require.Fail(t, "expected an error")
+	return ""
+}
+
+func TestRender(t *testing.T) {
+	cases := []struct {
+		src      string
+		expected string
+	}{
+		{
+			src: "(atom 'a)\n(conss 'a '(b))",
+			expected: "test:2:1: evaluation error: test:2:2: unbound symbol conss\n" +
+				"  |\n" +
+				"2 | (conss 'a '(b))\n" +
+				"  |  ^^^^^\n" +
+				"  = did you mean `cons`?\n",
+		},
+		{
+			src: "(car 'a\n  'b)",
+			expected: "test:1:1: evaluation error: car: expects 1 argument, got 2\n" +
+				"  |\n" +
+				"1 | (car 'a\n" +
+				"  | ^^^^^^^\n",
+		},
+		{
+			src: "\t(\"λ\" 'a)",
+			expected: "test:1:2: evaluation error: can not call `\"λ\"` as a function\n" +
+				"  |\n" +
+				"1 | \t(\"λ\" 'a)\n" +
+				"  | \t ^^^\n",
+		},
+		{
+			src: "(a))",
+			expected: "test:1:4: parse error: unexpected token ), can't close a list without first opening it\n" +
+				"  |\n" +
+				"1 | (a))\n" +
+				"  |    ^\n",
+		},
+		{
+			src:      "(zzz)",
+			expected: "test:1:1: evaluation error: test:1:2: unbound symbol zzz\n  |\n1 | (zzz)\n  |  ^^^\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.src, func(t *testing.T) {
+			assert.Equal(t, tc.expected, eval(t, tc.src))
+		})
+	}
+}
+
+func TestRenderColor(t *testing.T) {
+	r := Renderer{Color: true}
+	_, err := parser.Parse("test", 0, strings.NewReader(")"))
+	require.Error(t, err)
+	assert.Equal(t, "\x1b[1m\x1b[31mtest:1:1: parse error: unexpected token ), can't close a list without first opening it\x1b[0m\n", r.Render(err))
+}
+
+func TestSuggest(t *testing.T) {
+	names := []string{"cadar", "caddr", "cadr", "car", "cdr", "cons"}
+
+	name, ok := Suggest("cadrr", names)
+	require.True(t, ok)
+	assert.Equal(t, "cadr", name)
+
+	name, ok = Suggest("cnos", names)
+	require.True(t, ok)
+	assert.Equal(t, "cons", name)
+
+	_, ok = Suggest("lambda", names)
+	assert.False(t, ok)
+}
+
+func TestWindow(t *testing.T) {
+	w := NewWindow(2)
+	_, err := io.WriteString(w, "one\ntwo\nth")
+	require.NoError(t, err)
+	_, err = io.WriteString(w, "ree\r\nfour")
+	require.NoError(t, err)
+
+	sources := Windows{"test": w}
+	for line, expected := range map[uint]string{2: "two", 3: "three", 4: "four"} {
+		text, ok := sources.Line("test", line)
+		assert.True(t, ok, line)
+		assert.Equal(t, expected, text)
+	}
+	for _, line := range []uint{0, 1, 5} {
+		_, ok := sources.Line("test", line)
+		assert.False(t, ok, "line %d is not kept", line)
+	}
+	_, ok := sources.Line("other", 1)
+	assert.False(t, ok)
+
+	// long lines are truncated
+	w = NewWindow(1)
+	_, err = io.WriteString(w, strings.Repeat("x", 2*maxLineLen)+"\n")
+	require.NoError(t, err)
+	text, ok := w.Line(1)
+	assert.True(t, ok)
+	assert.Len(t, text, maxLineLen)
+}
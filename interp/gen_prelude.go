@@ -0,0 +1,72 @@
+//go:build ignore
+
+// gen_prelude writes prelude_gen.go, core.lisp parsed at build time,
+// so interpreters don't parse it on every start. Run `go generate ./interp`
+// after changing core.lisp.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/reflechant/minimal-lisp/core"
+	"github.com/reflechant/minimal-lisp/parser"
+)
+
+func main() {
+	text, err := os.ReadFile("core.lisp")
+	if err != nil {
+		log.Fatal(err)
+	}
+	exprs, err := parser.Parse("core.lisp", 0, bytes.NewReader(text))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by gen_prelude.go from core.lisp; DO NOT EDIT.\n\n")
+	b.WriteString("package interp\n\n")
+	b.WriteString("import \"github.com/reflechant/minimal-lisp/core\"\n\n")
+	b.WriteString("// coreForms are the forms of core.lisp\n")
+	b.WriteString("var coreForms = []core.SExpr{\n")
+	for _, e := range exprs {
+		writeExpr(&b, e)
+		b.WriteString(",\n")
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("prelude_gen.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeExpr writes the Go expression building e with its span
+func writeExpr(b *strings.Builder, e core.SExpr) {
+	s := e.Span()
+	span := fmt.Sprintf("genSpan(%d, %d, %d, %d, %d, %d)",
+		s.Start.Line, s.Start.Col, s.Start.Offset, s.End.Line, s.End.Col, s.End.Offset)
+
+	switch v := e.(type) {
+	case core.Symbol:
+		fmt.Fprintf(b, "genSym(%s, %q)", span, v.Name())
+	case core.String:
+		fmt.Fprintf(b, "genStr(%s, %q)", span, v.Value())
+	case core.List:
+		fmt.Fprintf(b, "genList(%s", span)
+		for item := range v.Items() {
+			b.WriteString(",\n")
+			writeExpr(b, item)
+		}
+		b.WriteString(")")
+	default:
+		log.Fatalf("unexpected %T in core.lisp", e)
+	}
+}
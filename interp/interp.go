@@ -0,0 +1,369 @@
+// Package interp is the embedding API of the interpreter.
+// It hides the details of the core, parser and lexer packages
+// behind a single Interpreter type:
+//
+//	it, err := interp.New()
+//	if err != nil {
+//		return err
+//	}
+//	result, err := it.EvalString("(eval. 'x '((x a) (y b)))")
+package interp
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/reflechant/minimal-lisp/core"
+	"github.com/reflechant/minimal-lisp/diag"
+	"github.com/reflechant/minimal-lisp/parser"
+)
+
+// Interpreter evaluates LISP code in a persistent global scope.
+// Definitions made by one call are visible to the following ones.
+// Its methods are safe to call from several goroutines,
+// evaluation is serialized.
+type Interpreter struct {
+	mu sync.Mutex
+
+	scope    core.Scope
+	caps     []core.Capability
+	limits   *core.Limits
+	prelude  bool
+	in       io.Reader
+	out      io.Writer
+	errOut   io.Writer
+	observer core.Observer
+	// sources keeps the last lines of evaluated code for error messages
+	sources     diag.Windows
+	sourceLines int
+	// preludeCode returns the prelude to evaluate, core.lisp by default
+	preludeCode func() (*Prelude, error)
+
+	loadPath        []string
+	isolatedModules bool
+	// modules are the modules loaded by `require` by their absolute paths
+	modules map[string]*module
+	// features maps the names given to `provide` and `require` to their modules,
+	// the module is nil for features provided outside of modules
+	features map[string]*module
+	// loading are the files being loaded, the innermost last
+	loading []*module
+	// watched are the files reloaded by Poll by their paths
+	watched map[string]*watchedFile
+	// defined are the values bound with Define
+	defined map[string]core.SExpr
+}
+
+// Option configures an Interpreter
+type Option func(it *Interpreter)
+
+// WithPrelude controls whether the prelude, core.lisp (`eval.` and its helpers)
+// unless replaced, is loaded on start. It is loaded by default.
+func WithPrelude(load bool) Option {
+	return func(it *Interpreter) {
+		it.prelude = load
+	}
+}
+
+// WithPreludeFS replaces core.lisp with the named files of fsys,
+// see CompilePrelude. They are parsed by New every time,
+// use WithCompiledPrelude to parse them once for many interpreters.
+func WithPreludeFS(fsys fs.FS, names ...string) Option {
+	return func(it *Interpreter) {
+		it.preludeCode = func() (*Prelude, error) {
+			return CompilePrelude(fsys, names...)
+		}
+	}
+}
+
+// WithCompiledPrelude replaces core.lisp with a prelude parsed by CompilePrelude.
+func WithCompiledPrelude(p *Prelude) Option {
+	return func(it *Interpreter) {
+		it.preludeCode = func() (*Prelude, error) {
+			return p, nil
+		}
+	}
+}
+
+// WithCapabilities restricts the builtins available to the evaluated code.
+// All capabilities are granted by default.
+func WithCapabilities(caps ...core.Capability) Option {
+	return func(it *Interpreter) {
+		it.caps = caps
+	}
+}
+
+// WithLimits applies resource limits to every evaluation call
+// (EvalString, EvalReader, LoadFile) separately.
+func WithLimits(l core.Limits) Option {
+	return func(it *Interpreter) {
+		it.limits = &l
+	}
+}
+
+// WithPorts sets the current input, output and error ports of evaluated code.
+// They default to the standard input, output and error of the process,
+// nil arguments keep the defaults.
+func WithPorts(in io.Reader, out, errOut io.Writer) Option {
+	return func(it *Interpreter) {
+		it.in, it.out, it.errOut = in, out, errOut
+	}
+}
+
+// WithObserver reports evaluation of all code (including the prelude) to o.
+func WithObserver(o core.Observer) Option {
+	return func(it *Interpreter) {
+		it.observer = o
+	}
+}
+
+// defaultSourceLines is the number of lines of every source kept for error messages
+const defaultSourceLines = 1000
+
+// WithSourceLines sets the number of last lines of every evaluated source
+// kept to show in error messages, 1000 by default. Lines before them
+// are shown without a snippet. 0 keeps no source text at all,
+// e.g. when streaming large data.
+func WithSourceLines(n int) Option {
+	return func(it *Interpreter) {
+		it.sourceLines = n
+	}
+}
+
+// WithLoadPath sets the directories `require` searches for modules
+// after the directory of the current file. The directories listed
+// in $MINILISP_PATH are searched after them.
+func WithLoadPath(dirs ...string) Option {
+	return func(it *Interpreter) {
+		it.loadPath = dirs
+	}
+}
+
+// WithIsolatedModules gives every module required with `require` its own scope.
+// Only the names a module lists with `export` are bound in the scope requiring it.
+// By default modules define their names in the global scope.
+func WithIsolatedModules(isolated bool) Option {
+	return func(it *Interpreter) {
+		it.isolatedModules = isolated
+	}
+}
+
+// New creates an interpreter and evaluates the prelude unless disabled.
+// The default prelude is parsed at build time, see gen_prelude.go.
+func New(opts ...Option) (*Interpreter, error) {
+	it := &Interpreter{
+		caps:        core.AllCapabilities,
+		prelude:     true,
+		preludeCode: defaultPrelude,
+		sourceLines: defaultSourceLines,
+		watched:     map[string]*watchedFile{},
+		defined:     map[string]core.SExpr{},
+	}
+	for _, opt := range opts {
+		opt(it)
+	}
+	it.loadPath = append(it.loadPath, defaultLoadPath()...)
+	if err := it.init(); err != nil {
+		return nil, err
+	}
+
+	return it, nil
+}
+
+// init creates the global scope and evaluates the prelude
+func (it *Interpreter) init() error {
+	it.scope = core.NewScope(it.caps...).WithPorts(it.in, it.out, it.errOut)
+	if it.observer != nil {
+		it.scope = it.scope.WithObserver(it.observer)
+	}
+	it.sources = diag.Windows{}
+	it.modules = map[string]*module{}
+	it.features = map[string]*module{}
+	it.bindModuleBuiltins()
+
+	if it.prelude {
+		p, err := it.preludeCode()
+		if err != nil {
+			return err
+		}
+		if err := it.evalPrelude(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reset starts over with a fresh global scope as if the interpreter was just
+// created: definitions, packages and loaded modules are dropped and the prelude
+// is evaluated again. The values bound with Define are bound again.
+// Watched files stay watched and are loaded again, failures to load them
+// are returned after the reset is complete.
+// Scopes returned by Scope before the reset are no longer used.
+func (it *Interpreter) Reset() error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	if err := it.init(); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(it.defined)) {
+		it.scope.Bind(name, it.defined[name])
+	}
+
+	errs := []error{}
+	for _, fpath := range slices.Sorted(maps.Keys(it.watched)) {
+		f := it.watched[fpath]
+		delete(it.watched, fpath)
+		if err := it.watchFile(fpath); err != nil {
+			// keep watching a broken file, its definitions are added once it's fixed
+			f.defs = map[string]string{}
+			it.watched[fpath] = f
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// EvalString evaluates all expressions in src and returns the value of the last one.
+func (it *Interpreter) EvalString(src string) (core.SExpr, error) {
+	return it.EvalReader("<string>", strings.NewReader(src))
+}
+
+// EvalReader evaluates all expressions read from src and returns the value of the last one.
+// srcName is used in error messages.
+// Expressions are evaluated as soon as they are read, so the ones before
+// a syntax error are evaluated. Evaluation stops at the first error.
+func (it *Interpreter) EvalReader(srcName string, src io.Reader) (core.SExpr, error) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	return it.eval(srcName, src, it.callScope())
+}
+
+// callScope returns the global scope with fresh limits for an evaluation call
+func (it *Interpreter) callScope() core.Scope {
+	if it.limits != nil {
+		return it.scope.WithLimits(*it.limits)
+	}
+
+	return it.scope
+}
+
+// eval evaluates the expressions read from src in scope
+func (it *Interpreter) eval(srcName string, src io.Reader, scope core.Scope) (core.SExpr, error) {
+	var result core.SExpr
+	for e, err := range parser.NewReader(srcName, 0, it.keepSource(srcName, src)).All() {
+		if err != nil {
+			return nil, err
+		}
+		result, err = e.Eval(scope)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// keepSource returns src keeping the last lines read from it for error messages
+func (it *Interpreter) keepSource(srcName string, src io.Reader) io.Reader {
+	if it.sourceLines <= 0 {
+		delete(it.sources, srcName)
+		return src
+	}
+	w := diag.NewWindow(it.sourceLines)
+	it.sources[srcName] = w
+
+	return io.TeeReader(src, w)
+}
+
+// LoadFile evaluates the file at fpath.
+// Modules it requires are searched relative to its directory first.
+func (it *Interpreter) LoadFile(fpath string) error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	return it.evalFile(&module{path: path.Clean(fpath), scope: it.callScope()})
+}
+
+// LoadFileAtomic evaluates the file at fpath like LoadFile but keeps its definitions
+// only if all of its forms succeed. The forms are evaluated in a staging layer
+// which is committed to the global scope at the end. Evaluation goes on after
+// a failure, so a *core.LoadError lists all of them.
+// Modules the file requires and packages it switches to are not rolled back.
+func (it *Interpreter) LoadFileAtomic(fpath string) error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	return it.loadAtomic(path.Clean(fpath))
+}
+
+func (it *Interpreter) loadAtomic(fpath string) error {
+	file, err := os.Open(fpath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	it.loading = append(it.loading, &module{path: fpath})
+	defer func() { it.loading = it.loading[:len(it.loading)-1] }()
+
+	forms := parser.NewReader(fpath, 0, it.keepSource(fpath, file)).All()
+	return it.callScope().LoadAtomic(fpath, forms, nil)
+}
+
+// FormatError renders err with the line of evaluated code it points to
+// and suggestions for misspelled names, see diag.Renderer.
+// color enables ANSI colors.
+func (it *Interpreter) FormatError(err error, color bool) string {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	r := diag.Renderer{
+		Color:  color,
+		Source: it.sources.Line,
+		Names:  it.scope.Names,
+	}
+
+	return r.Render(err)
+}
+
+// Define binds value to name in the global scope.
+// The binding is made again after Reset.
+func (it *Interpreter) Define(name string, value core.SExpr) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	it.defined[name] = value
+	it.scope.Bind(name, value)
+}
+
+// Lookup returns the value bound to name.
+func (it *Interpreter) Lookup(name string) (core.SExpr, bool) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	return it.scope.SymbolValue(name)
+}
+
+// Scope returns the global scope of the interpreter, e.g. to run a REPL in it.
+// Evaluating code in it directly while files are watched requires holding Locker.
+// Reset replaces the global scope.
+func (it *Interpreter) Scope() core.Scope {
+	return it.scope
+}
+
+// Locker returns the lock the methods of the interpreter and reloading
+// watched files hold while evaluating
+func (it *Interpreter) Locker() sync.Locker {
+	return &it.mu
+}
@@ -0,0 +1,228 @@
+package interp
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/reflechant/minimal-lisp/core"
+	"github.com/reflechant/minimal-lisp/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrelude(t *testing.T) {
+	it, err := New()
+	require.NoError(t, err)
+
+	result, err := it.EvalString("(eval. 'x '((x a) (y b)))")
+	require.NoError(t, err)
+	assert.Equal(t, "a", result.String())
+}
+
+func TestWithoutPrelude(t *testing.T) {
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+
+	_, ok := it.Lookup("eval.")
+	assert.False(t, ok)
+}
+
+func TestPreludeNotParsed(t *testing.T) {
+	parsed := []string{}
+	t.Cleanup(func() { parse = parser.Parse })
+	parse = func(srcName string, line uint, r io.Reader) ([]core.SExpr, error) {
+		parsed = append(parsed, srcName)
+		return parser.Parse(srcName, line, r)
+	}
+
+	// as created by the command line
+	it, err := New(WithPrelude(true), WithPorts(strings.NewReader(""), io.Discard, io.Discard))
+	require.NoError(t, err)
+	require.NoError(t, it.Reset())
+	assert.Empty(t, parsed, "core.lisp is parsed at build time")
+}
+
+func TestGeneratedPrelude(t *testing.T) {
+	p, err := CompilePrelude(os.DirFS("."), "core.lisp")
+	require.NoError(t, err)
+	assert.Equal(t, p.files[0].exprs, coreForms, "prelude_gen.go is stale, run go generate ./interp")
+	assert.Equal(t, p.files[0].text, coreText)
+}
+
+func TestPreludeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"b.lisp":   {Data: []byte("(defun second (x) (car (rest x)))\n")},
+		"a.lisp":   {Data: []byte("(defun rest (x) (cdr x))\n")},
+		"notes.md": {Data: []byte("not lisp")},
+	}
+
+	it, err := New(WithPreludeFS(fsys))
+	require.NoError(t, err)
+	result, err := it.EvalString("(second '(a b))")
+	require.NoError(t, err)
+	assert.Equal(t, "b", result.String())
+	_, ok := it.Lookup("eval.")
+	assert.False(t, ok, "the prelude replaces core.lisp")
+
+	_, err = New(WithPreludeFS(fsys, "missing.lisp"))
+	assert.ErrorContains(t, err, "prelude: open missing.lisp")
+}
+
+func TestCompiledPrelude(t *testing.T) {
+	p, err := CompilePrelude(fstest.MapFS{
+		"lib.lisp": {Data: []byte("(defun f () 'a)\n(defun g () (car x))\n")},
+	})
+	require.NoError(t, err)
+
+	// the parsed prelude is shared, not consumed
+	for range 2 {
+		it, err := New(WithCompiledPrelude(p))
+		require.NoError(t, err)
+		result, err := it.EvalString("(f)")
+		require.NoError(t, err)
+		assert.Equal(t, "a", result.String())
+
+		// errors in prelude code point to its source
+		_, err = it.EvalString("(g)")
+		require.Error(t, err)
+		assert.Contains(t, it.FormatError(err, false), "2 | (defun g () (car x))")
+	}
+
+	_, err = CompilePrelude(fstest.MapFS{"bad.lisp": {Data: []byte("(car")}})
+	assert.ErrorContains(t, err, "prelude: bad.lisp:1")
+}
+
+func TestDefineLookup(t *testing.T) {
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+
+	it.Define("x", core.NewSymbol("", 0, 0, "a"))
+	result, err := it.EvalString("(cons x '(b))")
+	require.NoError(t, err)
+	assert.Equal(t, "(a b)", result.String())
+
+	_, err = it.EvalString("(defun f (y) (cons y '()))")
+	require.NoError(t, err)
+	_, ok := it.Lookup("f")
+	assert.True(t, ok)
+}
+
+func TestLoadFile(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "lib.lisp")
+	require.NoError(t, os.WriteFile(fpath, []byte("(defun second (x) (car (cdr x)))"), 0o644))
+
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+	require.NoError(t, it.LoadFile(fpath))
+
+	result, err := it.EvalString("(second '(a b c))")
+	require.NoError(t, err)
+	assert.Equal(t, "b", result.String())
+}
+
+func TestLimitsPerCall(t *testing.T) {
+	it, err := New(WithPrelude(false), WithLimits(core.Limits{MaxSteps: 10}))
+	require.NoError(t, err)
+
+	_, err = it.EvalString("(defun loop (x) (loop x))")
+	require.NoError(t, err)
+	_, err = it.EvalString("(loop 'a)")
+	var limitErr core.LimitError
+	require.ErrorAs(t, err, &limitErr)
+
+	// the next call starts with fresh counters
+	_, err = it.EvalString("(car '(a))")
+	require.NoError(t, err)
+
+	// but other state of the session carries over
+	var out strings.Builder
+	it, err = New(WithPrelude(false), WithLimits(core.Limits{MaxSteps: 10}), WithPorts(nil, &out, nil))
+	require.NoError(t, err)
+	_, err = it.EvalString("(defun f (x) x) (trace f)")
+	require.NoError(t, err)
+	_, err = it.EvalString("(f 'a)")
+	require.NoError(t, err)
+	assert.Equal(t, "0: (f a)\n0: f returned a\n", out.String())
+}
+
+func TestFormatError(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "lib.lisp")
+	require.NoError(t, os.WriteFile(fpath, []byte("(defun second (x) (car (cdr x)))\n(seccond '(a b))\n"), 0o644))
+
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+	err = it.LoadFile(fpath)
+	require.Error(t, err)
+
+	expected := fpath + ":2:1: evaluation error: " + fpath + ":2:2: unbound symbol seccond\n" +
+		"  |\n" +
+		"2 | (seccond '(a b))\n" +
+		"  |  ^^^^^^^\n" +
+		"  = did you mean `second`?\n"
+	assert.Equal(t, expected, it.FormatError(err, false))
+}
+
+func TestEvalReaderStreams(t *testing.T) {
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+
+	_, err = it.EvalString("(defun first (x) (car x))\n(first '(a b)))")
+	var parseErr parser.ParseError
+	require.ErrorAs(t, err, &parseErr)
+
+	// the forms before the syntax error were evaluated
+	_, ok := it.Lookup("first")
+	assert.True(t, ok)
+}
+
+func TestSourceLines(t *testing.T) {
+	src := "'a\n'b\n(car x)\n"
+
+	it, err := New(WithPrelude(false), WithSourceLines(2))
+	require.NoError(t, err)
+	_, err = it.EvalString(src)
+	assert.Contains(t, it.FormatError(err, false), "3 | (car x)")
+
+	// without kept sources errors have no snippets
+	it, err = New(WithPrelude(false), WithSourceLines(0))
+	require.NoError(t, err)
+	_, err = it.EvalString(src)
+	assert.NotContains(t, it.FormatError(err, false), "(car x)")
+}
+
+func TestLoadFileAtomic(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "lib.lisp")
+	src := "(defun first (x) (car x))\n(car)\n(defun second (x) (car (cdr x)))\n(cdr)\n"
+	require.NoError(t, os.WriteFile(fpath, []byte(src), 0o644))
+
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+	err = it.LoadFileAtomic(fpath)
+	var loadErr *core.LoadError
+	require.ErrorAs(t, err, &loadErr)
+	// all the failures are reported
+	assert.Len(t, loadErr.Errs, 2)
+	_, ok := it.Lookup("first")
+	assert.False(t, ok)
+
+	expected := fpath + ": loading failed, no definitions were kept:\n" +
+		fpath + ":2:1: evaluation error: car: expects 1 argument, got 0\n" +
+		"  |\n" +
+		"2 | (car)\n" +
+		"  | ^^^^^\n" +
+		fpath + ":4:1: evaluation error: cdr: expects 1 argument, got 0\n" +
+		"  |\n" +
+		"4 | (cdr)\n" +
+		"  | ^^^^^\n"
+	assert.Equal(t, expected, it.FormatError(err, false))
+
+	require.NoError(t, os.WriteFile(fpath, []byte("(defun first (x) (car x))\n(defun second (x) (car (cdr x)))\n"), 0o644))
+	require.NoError(t, it.LoadFileAtomic(fpath))
+	result, err := it.EvalString("(first (cons (second '(a b)) '()))")
+	require.NoError(t, err)
+	assert.Equal(t, "b", result.String())
+}
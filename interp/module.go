@@ -0,0 +1,267 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/reflechant/minimal-lisp/core"
+)
+
+// loadPathEnv is the environment variable listing directories searched by `require`
+const loadPathEnv = "MINILISP_PATH"
+
+// module is a file loaded by `require` (or `load`)
+type module struct {
+	path  string
+	scope core.Scope
+	// exports are the names declared with `export`
+	exports []string
+	// loaded is false while the module is being loaded
+	loaded bool
+}
+
+// bindModuleBuiltins adds `load`, `require`, `provide`, `export`
+// and `watch-file` to the global scope if the FileIO capability is granted,
+// otherwise using them reports that it's required
+func (it *Interpreter) bindModuleBuiltins() {
+	granted := slices.Contains(it.caps, core.FileIO)
+	for _, fn := range []core.Fn{
+		core.NewFn("load", it.load).
+			WithDoc(`(load "file") evaluates a file, relative paths start from the directory of the current file`),
+		core.NewFn("require", it.require).
+			WithDoc("(require 'name) loads name.lisp from the directory of the current file or the load path once"),
+		core.NewFn("provide", it.provide).
+			WithDoc("(provide 'name) records that the feature name is loaded, so (require 'name) does nothing"),
+		core.NewFn("export", it.export).
+			WithDoc("(export 'name ...) makes definitions of the current module visible to its requirers"),
+		core.NewFn("watch-file", it.watchFileFn).
+			WithDoc(`(watch-file "file") loads a file and reloads its changed definitions whenever it changes`),
+	} {
+		if granted {
+			it.scope.Bind(fn.Name(), fn)
+		} else {
+			it.scope.Withhold(core.FileIO, fn.Name())
+		}
+	}
+}
+
+// load evaluates a file in the scope of the caller every time it's called
+func (it *Interpreter) load(scope core.Scope, args ...core.SExpr) (core.SExpr, error) {
+	name, err := it.nameArg("load", scope, args)
+	if err != nil {
+		return nil, err
+	}
+
+	fpath := name
+	if !filepath.IsAbs(fpath) {
+		fpath = filepath.Join(it.currentDir(), fpath)
+	}
+	if err := it.evalFile(&module{path: fpath, scope: scope}); err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+
+	return core.Void, nil
+}
+
+// require loads a module once. Modules share the global scope
+// unless they are isolated, then only the exports of the module
+// are bound in the scope of the caller.
+func (it *Interpreter) require(scope core.Scope, args ...core.SExpr) (core.SExpr, error) {
+	name, err := it.nameArg("require", scope, args)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := it.features[name]
+	if !ok {
+		fpath, err := it.findModule(name)
+		if err != nil {
+			return nil, err
+		}
+		m, ok = it.modules[fpath]
+		if ok && !m.loaded {
+			return nil, errors.New(fmt.Sprintf("require: circular dependency %s", it.requireChain(fpath)))
+		}
+		if !ok {
+			m = &module{path: fpath, scope: it.scope.WithSessionOf(scope)}
+			if it.isolatedModules {
+				m.scope = m.scope.NewLayer()
+			}
+			it.modules[fpath] = m
+			if err := it.evalFile(m); err != nil {
+				// the module may be fixed and required again
+				delete(it.modules, fpath)
+				return nil, fmt.Errorf("require %s: %w", name, err)
+			}
+			m.loaded = true
+		}
+		it.features[name] = m
+	}
+
+	if m != nil && it.isolatedModules {
+		return core.Void, it.importExports(scope, m)
+	}
+
+	return core.Void, nil
+}
+
+// provide records a feature, requiring it later does nothing
+func (it *Interpreter) provide(scope core.Scope, args ...core.SExpr) (core.SExpr, error) {
+	name, err := it.nameArg("provide", scope, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var current *module
+	if len(it.loading) > 0 {
+		current = it.loading[len(it.loading)-1]
+	}
+	it.features[name] = current
+
+	return core.Void, nil
+}
+
+// export declares the names an isolated module binds in the scope of its requirers
+func (it *Interpreter) export(scope core.Scope, args ...core.SExpr) (core.SExpr, error) {
+	if len(it.loading) == 0 {
+		return nil, errors.New("export: can only be used in a module")
+	}
+	current := it.loading[len(it.loading)-1]
+
+	for i, a := range args {
+		v, err := a.Eval(scope)
+		if err != nil {
+			return nil, fmt.Errorf("export: %w", err)
+		}
+		sym, ok := v.(core.Symbol)
+		if !ok {
+			return nil, core.TypeError{Fn: "export", Arg: i + 1, Expected: "a symbol", Got: v}
+		}
+		current.exports = append(current.exports, sym.Name())
+	}
+
+	return core.Void, nil
+}
+
+// importExports binds the exports of m in scope. Exported functions are
+// called in the scope of the module, so they see its internal definitions.
+func (it *Interpreter) importExports(scope core.Scope, m *module) error {
+	for _, name := range m.exports {
+		v, ok := m.scope.SymbolValue(name)
+		if !ok {
+			return errors.New(fmt.Sprintf("require: %s exports %s which is not defined", m.path, name))
+		}
+		if fn, ok := v.(core.Fn); ok {
+			v = moduleFn(fn, m.scope)
+		}
+		scope.Bind(name, v)
+	}
+
+	return nil
+}
+
+// moduleFn wraps fn to evaluate its arguments in the scope of the caller
+// and its body in the module scope
+func moduleFn(fn core.Fn, moduleScope core.Scope) core.Fn {
+	return core.NewFn(fn.Name(), func(scope core.Scope, args ...core.SExpr) (core.SExpr, error) {
+		vals := make([]core.SExpr, len(args))
+		for i, a := range args {
+			v, err := a.Eval(scope)
+			if err != nil {
+				return nil, fmt.Errorf("%s: argument #%d evaluation error: %w", fn.Name(), i+1, err)
+			}
+			vals[i] = v
+		}
+
+		return fn.Apply(moduleScope.WithSessionOf(scope), vals...)
+	}).WithDoc(fn.Doc())
+}
+
+// evalFile evaluates the file of m in its scope
+func (it *Interpreter) evalFile(m *module) error {
+	file, err := os.Open(m.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	it.loading = append(it.loading, m)
+	defer func() { it.loading = it.loading[:len(it.loading)-1] }()
+
+	_, err = it.eval(m.path, file, m.scope)
+	return err
+}
+
+// findModule returns the path of the file of a module:
+// name.lisp in the directory of the current file or in the load path
+func (it *Interpreter) findModule(name string) (string, error) {
+	fname := name
+	if filepath.Ext(fname) == "" {
+		fname += ".lisp"
+	}
+	if filepath.IsAbs(fname) {
+		return fname, nil
+	}
+
+	dirs := append([]string{it.currentDir()}, it.loadPath...)
+	for _, dir := range dirs {
+		fpath := filepath.Join(dir, fname)
+		if _, err := os.Stat(fpath); err == nil {
+			return filepath.Abs(fpath)
+		}
+	}
+
+	return "", errors.New(fmt.Sprintf("require: module %s not found in %s", name, strings.Join(dirs, ", ")))
+}
+
+// currentDir returns the directory of the file being loaded,
+// the working directory when evaluating code from elsewhere
+func (it *Interpreter) currentDir() string {
+	if len(it.loading) == 0 {
+		return "."
+	}
+
+	return filepath.Dir(it.loading[len(it.loading)-1].path)
+}
+
+// requireChain describes the requires leading to a circular dependency on fpath
+func (it *Interpreter) requireChain(fpath string) string {
+	chain := []string{}
+	for _, m := range it.loading {
+		if m.path == fpath || len(chain) > 0 {
+			chain = append(chain, filepath.Base(m.path))
+		}
+	}
+	chain = append(chain, filepath.Base(fpath))
+
+	return strings.Join(chain, " -> ")
+}
+
+// nameArg evaluates the only argument of fn which is a module or a file name
+func (it *Interpreter) nameArg(fn string, scope core.Scope, args []core.SExpr) (string, error) {
+	if len(args) != 1 {
+		return "", core.ArityError{Fn: fn, Min: 1, Max: 1, Got: len(args)}
+	}
+	v, err := args[0].Eval(scope)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", fn, err)
+	}
+
+	switch v := v.(type) {
+	case core.Symbol:
+		return v.Name(), nil
+	case core.String:
+		return v.Value(), nil
+	}
+
+	return "", core.TypeError{Fn: fn, Arg: 1, Expected: "a symbol or a string", Got: v}
+}
+
+// defaultLoadPath returns the directories listed in $MINILISP_PATH
+func defaultLoadPath() []string {
+	return filepath.SplitList(os.Getenv(loadPathEnv))
+}
@@ -0,0 +1,126 @@
+package interp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reflechant/minimal-lisp/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles creates files with the given contents in a temporary directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		fpath := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fpath), 0o755))
+		require.NoError(t, os.WriteFile(fpath, []byte(content), 0o644))
+	}
+
+	return dir
+}
+
+func TestRequireOnce(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.lisp": "(require 'util)\n(require \"util.lisp\")\n(print (second '(a b)))\n",
+		"util.lisp": "(print 'loading)\n(defun second (x) (car (cdr x)))\n",
+	})
+
+	var out strings.Builder
+	it, err := New(WithPrelude(false), WithPorts(nil, &out, nil))
+	require.NoError(t, err)
+	// modules are searched next to the file requiring them, not in the working directory
+	require.NoError(t, it.LoadFile(filepath.Join(dir, "main.lisp")))
+	assert.Equal(t, "loading\nb\n", out.String())
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.lisp":     "(load \"lib/util.lisp\")\n(load \"lib/util.lisp\")\n",
+		"lib/util.lisp": "(print 'loading)\n",
+	})
+
+	var out strings.Builder
+	it, err := New(WithPrelude(false), WithPorts(nil, &out, nil))
+	require.NoError(t, err)
+	require.NoError(t, it.LoadFile(filepath.Join(dir, "main.lisp")))
+	assert.Equal(t, "loading\nloading\n", out.String())
+}
+
+func TestLoadPath(t *testing.T) {
+	libDir := writeFiles(t, map[string]string{"a.lisp": "(defun a () 'a)\n"})
+	envDir := writeFiles(t, map[string]string{"b.lisp": "(defun b () 'b)\n"})
+	t.Setenv(loadPathEnv, envDir)
+
+	it, err := New(WithPrelude(false), WithLoadPath(libDir))
+	require.NoError(t, err)
+	result, err := it.EvalString("(require 'a) (require 'b) (cons (a) (cons (b) '()))")
+	require.NoError(t, err)
+	assert.Equal(t, "(a b)", result.String())
+
+	_, err = it.EvalString("(require 'c)")
+	require.ErrorContains(t, err, "require: module c not found in ., "+libDir+", "+envDir)
+}
+
+func TestCircularRequire(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.lisp": "(require 'b)\n",
+		"b.lisp": "(require 'c)\n",
+		"c.lisp": "(require 'a)\n",
+	})
+
+	it, err := New(WithPrelude(false), WithLoadPath(dir))
+	require.NoError(t, err)
+	_, err = it.EvalString("(require 'a)")
+	require.ErrorContains(t, err, "require: circular dependency a.lisp -> b.lisp -> c.lisp -> a.lisp")
+
+	// the failed modules can be required again once fixed
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.lisp"), nil, 0o644))
+	_, err = it.EvalString("(require 'a)")
+	require.NoError(t, err)
+}
+
+func TestProvide(t *testing.T) {
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+	_, err = it.EvalString("(provide 'feature) (require 'feature)")
+	require.NoError(t, err)
+}
+
+func TestIsolatedModules(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"list.lisp": "(export 'second)\n" +
+			"(defun rest (x) (cdr x))\n" +
+			"(defun second (x) (car (rest x)))\n",
+	})
+
+	it, err := New(WithPrelude(false), WithLoadPath(dir), WithIsolatedModules(true))
+	require.NoError(t, err)
+	result, err := it.EvalString("(require 'list) (second '(a b))")
+	require.NoError(t, err)
+	assert.Equal(t, "b", result.String())
+
+	// internal definitions are not visible
+	_, ok := it.Lookup("rest")
+	assert.False(t, ok)
+
+	_, err = it.EvalString("(export 'second)")
+	require.ErrorContains(t, err, "export: can only be used in a module")
+}
+
+func TestModulesWithoutFileIO(t *testing.T) {
+	it, err := New(WithPrelude(false), WithCapabilities())
+	require.NoError(t, err)
+	_, ok := it.Lookup("require")
+	assert.False(t, ok)
+
+	_, err = it.EvalString(`(load "x.lisp")`)
+	var capErr core.CapabilityError
+	require.ErrorAs(t, err, &capErr)
+	assert.Equal(t, core.FileIO, capErr.Capability)
+	assert.ErrorContains(t, err, "load requires capability file-io")
+}
@@ -0,0 +1,108 @@
+package interp
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/reflechant/minimal-lisp/core"
+	"github.com/reflechant/minimal-lisp/parser"
+)
+
+//go:generate go run gen_prelude.go
+
+//go:embed core.lisp
+var coreText string
+
+// parse is parser.Parse, replaced in tests to see what's parsed
+var parse = parser.Parse
+
+// Prelude is parsed code an interpreter evaluates on start.
+// Parsed code is never modified, so a Prelude compiled once
+// may be shared by any number of interpreters.
+type Prelude struct {
+	files []preludeFile
+}
+
+type preludeFile struct {
+	name string
+	// text is kept for error messages
+	text  string
+	exprs []core.SExpr
+}
+
+// CompilePrelude parses the named files of fsys to be evaluated in order.
+// Without names all of the .lisp files at the root of fsys are parsed
+// in lexical order.
+func CompilePrelude(fsys fs.FS, names ...string) (*Prelude, error) {
+	if len(names) == 0 {
+		var err error
+		if names, err = fs.Glob(fsys, "*.lisp"); err != nil {
+			return nil, fmt.Errorf("prelude: %w", err)
+		}
+	}
+
+	p := &Prelude{}
+	for _, name := range names {
+		text, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("prelude: %w", err)
+		}
+		exprs, err := parse(name, 0, bytes.NewReader(text))
+		if err != nil {
+			return nil, fmt.Errorf("prelude: %w", err)
+		}
+		p.files = append(p.files, preludeFile{name: name, text: string(text), exprs: exprs})
+	}
+
+	return p, nil
+}
+
+// defaultPrelude is core.lisp, its forms are generated at build time
+// by gen_prelude.go, so it's never parsed
+func defaultPrelude() (*Prelude, error) {
+	return &Prelude{files: []preludeFile{{name: coreFile, text: coreText, exprs: coreForms}}}, nil
+}
+
+// coreFile is the source name of the default prelude
+const coreFile = "core.lisp"
+
+// genSpan, genSym, genStr and genList build the forms of prelude_gen.go
+func genSpan(line, col, offset, endLine, endCol, endOffset uint) core.Span {
+	return core.Span{
+		Start: core.Position{SrcName: coreFile, Line: line, Col: col, Offset: offset},
+		End:   core.Position{SrcName: coreFile, Line: endLine, Col: endCol, Offset: endOffset},
+	}
+}
+
+func genSym(span core.Span, name string) core.SExpr {
+	return core.NewSymbol(coreFile, span.Start.Line, span.Start.Col, name).WithSpan(span)
+}
+
+func genStr(span core.Span, val string) core.SExpr {
+	return core.NewString(coreFile, span.Start.Line, span.Start.Col, val).WithSpan(span)
+}
+
+func genList(span core.Span, items ...core.SExpr) core.SExpr {
+	return core.NewList(coreFile, span.Start.Line, span.Start.Col, items...).WithSpan(span)
+}
+
+// evalPrelude evaluates p in the global scope, every file counts
+// as a separate evaluation call for the limits
+func (it *Interpreter) evalPrelude(p *Prelude) error {
+	for _, f := range p.files {
+		// reading the text through keepSource keeps it for error messages
+		io.Copy(io.Discard, it.keepSource(f.name, strings.NewReader(f.text)))
+		scope := it.callScope()
+		for _, e := range f.exprs {
+			if _, err := e.Eval(scope); err != nil {
+				return fmt.Errorf("prelude: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,524 @@
+// Code generated by gen_prelude.go from core.lisp; DO NOT EDIT.
+
+package interp
+
+import "github.com/reflechant/minimal-lisp/core"
+
+// coreForms are the forms of core.lisp
+var coreForms = []core.SExpr{
+	genList(genSpan(9, 1, 336, 10, 14, 366),
+		genSym(genSpan(9, 2, 337, 9, 7, 342), "defun"),
+		genSym(genSpan(9, 8, 343, 9, 13, 348), "null."),
+		genList(genSpan(9, 14, 349, 9, 17, 352),
+			genSym(genSpan(9, 15, 350, 9, 16, 351), "x")),
+		genList(genSpan(10, 3, 355, 10, 13, 365),
+			genSym(genSpan(10, 4, 356, 10, 6, 358), "eq"),
+			genSym(genSpan(10, 7, 359, 10, 8, 360), "x"),
+			genList(genSpan(10, 9, 361, 10, 12, 364),
+				genSym(genSpan(10, 9, 361, 10, 10, 362), "quote"),
+				genList(genSpan(10, 10, 362, 10, 12, 364))))),
+	genList(genSpan(12, 1, 368, 14, 19, 439),
+		genSym(genSpan(12, 2, 369, 12, 7, 374), "defun"),
+		genSym(genSpan(12, 8, 375, 12, 12, 379), "and."),
+		genList(genSpan(12, 13, 380, 12, 18, 385),
+			genSym(genSpan(12, 14, 381, 12, 15, 382), "x"),
+			genSym(genSpan(12, 16, 383, 12, 17, 384), "y")),
+		genList(genSpan(13, 3, 388, 14, 18, 438),
+			genSym(genSpan(13, 4, 389, 13, 8, 393), "cond"),
+			genList(genSpan(13, 9, 394, 13, 35, 420),
+				genSym(genSpan(13, 10, 395, 13, 11, 396), "x"),
+				genList(genSpan(13, 12, 397, 13, 34, 419),
+					genSym(genSpan(13, 13, 398, 13, 17, 402), "cond"),
+					genList(genSpan(13, 18, 403, 13, 24, 409),
+						genSym(genSpan(13, 19, 404, 13, 20, 405), "y"),
+						genList(genSpan(13, 21, 406, 13, 23, 408),
+							genSym(genSpan(13, 21, 406, 13, 22, 407), "quote"),
+							genSym(genSpan(13, 22, 407, 13, 23, 408), "t"))),
+					genList(genSpan(13, 25, 410, 13, 33, 418),
+						genList(genSpan(13, 26, 411, 13, 28, 413),
+							genSym(genSpan(13, 26, 411, 13, 27, 412), "quote"),
+							genSym(genSpan(13, 27, 412, 13, 28, 413), "t")),
+						genList(genSpan(13, 29, 414, 13, 32, 417),
+							genSym(genSpan(13, 29, 414, 13, 30, 415), "quote"),
+							genList(genSpan(13, 30, 415, 13, 32, 417)))))),
+			genList(genSpan(14, 9, 429, 14, 17, 437),
+				genList(genSpan(14, 10, 430, 14, 12, 432),
+					genSym(genSpan(14, 10, 430, 14, 11, 431), "quote"),
+					genSym(genSpan(14, 11, 431, 14, 12, 432), "t")),
+				genList(genSpan(14, 13, 433, 14, 16, 436),
+					genSym(genSpan(14, 13, 433, 14, 14, 434), "quote"),
+					genList(genSpan(14, 14, 434, 14, 16, 436)))))),
+	genList(genSpan(16, 1, 441, 18, 18, 490),
+		genSym(genSpan(16, 2, 442, 16, 7, 447), "defun"),
+		genSym(genSpan(16, 8, 448, 16, 12, 452), "not."),
+		genList(genSpan(16, 13, 453, 16, 16, 456),
+			genSym(genSpan(16, 14, 454, 16, 15, 455), "x")),
+		genList(genSpan(17, 3, 459, 18, 17, 489),
+			genSym(genSpan(17, 4, 460, 17, 8, 464), "cond"),
+			genList(genSpan(17, 9, 465, 17, 16, 472),
+				genSym(genSpan(17, 10, 466, 17, 11, 467), "x"),
+				genList(genSpan(17, 12, 468, 17, 15, 471),
+					genSym(genSpan(17, 12, 468, 17, 13, 469), "quote"),
+					genList(genSpan(17, 13, 469, 17, 15, 471)))),
+			genList(genSpan(18, 9, 481, 18, 16, 488),
+				genList(genSpan(18, 10, 482, 18, 12, 484),
+					genSym(genSpan(18, 10, 482, 18, 11, 483), "quote"),
+					genSym(genSpan(18, 11, 483, 18, 12, 484), "t")),
+				genList(genSpan(18, 13, 485, 18, 15, 487),
+					genSym(genSpan(18, 13, 485, 18, 14, 486), "quote"),
+					genSym(genSpan(18, 14, 486, 18, 15, 487), "t"))))),
+	genList(genSpan(20, 1, 492, 22, 50, 584),
+		genSym(genSpan(20, 2, 493, 20, 7, 498), "defun"),
+		genSym(genSpan(20, 8, 499, 20, 15, 506), "append."),
+		genList(genSpan(20, 16, 507, 20, 21, 512),
+			genSym(genSpan(20, 17, 508, 20, 18, 509), "x"),
+			genSym(genSpan(20, 19, 510, 20, 20, 511), "y")),
+		genList(genSpan(21, 3, 515, 22, 49, 583),
+			genSym(genSpan(21, 4, 516, 21, 8, 520), "cond"),
+			genList(genSpan(21, 9, 521, 21, 22, 534),
+				genList(genSpan(21, 10, 522, 21, 19, 531),
+					genSym(genSpan(21, 11, 523, 21, 16, 528), "null."),
+					genSym(genSpan(21, 17, 529, 21, 18, 530), "x")),
+				genSym(genSpan(21, 20, 532, 21, 21, 533), "y")),
+			genList(genSpan(22, 9, 543, 22, 48, 582),
+				genList(genSpan(22, 10, 544, 22, 12, 546),
+					genSym(genSpan(22, 10, 544, 22, 11, 545), "quote"),
+					genSym(genSpan(22, 11, 545, 22, 12, 546), "t")),
+				genList(genSpan(22, 13, 547, 22, 47, 581),
+					genSym(genSpan(22, 14, 548, 22, 18, 552), "cons"),
+					genList(genSpan(22, 19, 553, 22, 26, 560),
+						genSym(genSpan(22, 20, 554, 22, 23, 557), "car"),
+						genSym(genSpan(22, 24, 558, 22, 25, 559), "x")),
+					genList(genSpan(22, 27, 561, 22, 46, 580),
+						genSym(genSpan(22, 28, 562, 22, 35, 569), "append."),
+						genList(genSpan(22, 36, 570, 22, 43, 577),
+							genSym(genSpan(22, 37, 571, 22, 40, 574), "cdr"),
+							genSym(genSpan(22, 41, 575, 22, 42, 576), "x")),
+						genSym(genSpan(22, 44, 578, 22, 45, 579), "y")))))),
+	genList(genSpan(24, 1, 586, 25, 25, 629),
+		genSym(genSpan(24, 2, 587, 24, 7, 592), "defun"),
+		genSym(genSpan(24, 8, 593, 24, 13, 598), "list."),
+		genList(genSpan(24, 14, 599, 24, 19, 604),
+			genSym(genSpan(24, 15, 600, 24, 16, 601), "x"),
+			genSym(genSpan(24, 17, 602, 24, 18, 603), "y")),
+		genList(genSpan(25, 3, 607, 25, 24, 628),
+			genSym(genSpan(25, 4, 608, 25, 8, 612), "cons"),
+			genSym(genSpan(25, 9, 613, 25, 10, 614), "x"),
+			genList(genSpan(25, 11, 615, 25, 23, 627),
+				genSym(genSpan(25, 12, 616, 25, 16, 620), "cons"),
+				genSym(genSpan(25, 17, 621, 25, 18, 622), "y"),
+				genList(genSpan(25, 19, 623, 25, 22, 626),
+					genSym(genSpan(25, 19, 623, 25, 20, 624), "quote"),
+					genList(genSpan(25, 20, 624, 25, 22, 626)))))),
+	genList(genSpan(27, 1, 631, 31, 43, 820),
+		genSym(genSpan(27, 2, 632, 27, 7, 637), "defun"),
+		genSym(genSpan(27, 8, 638, 27, 13, 643), "pair."),
+		genList(genSpan(27, 14, 644, 27, 19, 649),
+			genSym(genSpan(27, 15, 645, 27, 16, 646), "x"),
+			genSym(genSpan(27, 17, 647, 27, 18, 648), "y")),
+		genList(genSpan(28, 3, 652, 31, 42, 819),
+			genSym(genSpan(28, 4, 653, 28, 8, 657), "cond"),
+			genList(genSpan(28, 9, 658, 28, 41, 690),
+				genList(genSpan(28, 10, 659, 28, 36, 685),
+					genSym(genSpan(28, 11, 660, 28, 15, 664), "and."),
+					genList(genSpan(28, 16, 665, 28, 25, 674),
+						genSym(genSpan(28, 17, 666, 28, 22, 671), "null."),
+						genSym(genSpan(28, 23, 672, 28, 24, 673), "x")),
+					genList(genSpan(28, 26, 675, 28, 35, 684),
+						genSym(genSpan(28, 27, 676, 28, 32, 681), "null."),
+						genSym(genSpan(28, 33, 682, 28, 34, 683), "y"))),
+				genList(genSpan(28, 37, 686, 28, 40, 689),
+					genSym(genSpan(28, 37, 686, 28, 38, 687), "quote"),
+					genList(genSpan(28, 38, 687, 28, 40, 689)))),
+			genList(genSpan(29, 9, 699, 31, 41, 818),
+				genList(genSpan(29, 10, 700, 29, 48, 738),
+					genSym(genSpan(29, 11, 701, 29, 15, 705), "and."),
+					genList(genSpan(29, 16, 706, 29, 31, 721),
+						genSym(genSpan(29, 17, 707, 29, 21, 711), "not."),
+						genList(genSpan(29, 22, 712, 29, 30, 720),
+							genSym(genSpan(29, 23, 713, 29, 27, 717), "atom"),
+							genSym(genSpan(29, 28, 718, 29, 29, 719), "x"))),
+					genList(genSpan(29, 32, 722, 29, 47, 737),
+						genSym(genSpan(29, 33, 723, 29, 37, 727), "not."),
+						genList(genSpan(29, 38, 728, 29, 46, 736),
+							genSym(genSpan(29, 39, 729, 29, 43, 733), "atom"),
+							genSym(genSpan(29, 44, 734, 29, 45, 735), "y")))),
+				genList(genSpan(30, 10, 748, 31, 40, 817),
+					genSym(genSpan(30, 11, 749, 30, 15, 753), "cons"),
+					genList(genSpan(30, 16, 754, 30, 39, 777),
+						genSym(genSpan(30, 17, 755, 30, 22, 760), "list."),
+						genList(genSpan(30, 23, 761, 30, 30, 768),
+							genSym(genSpan(30, 24, 762, 30, 27, 765), "car"),
+							genSym(genSpan(30, 28, 766, 30, 29, 767), "x")),
+						genList(genSpan(30, 31, 769, 30, 38, 776),
+							genSym(genSpan(30, 32, 770, 30, 35, 773), "car"),
+							genSym(genSpan(30, 36, 774, 30, 37, 775), "y"))),
+					genList(genSpan(31, 16, 793, 31, 39, 816),
+						genSym(genSpan(31, 17, 794, 31, 22, 799), "pair."),
+						genList(genSpan(31, 23, 800, 31, 30, 807),
+							genSym(genSpan(31, 24, 801, 31, 27, 804), "cdr"),
+							genSym(genSpan(31, 28, 805, 31, 29, 806), "x")),
+						genList(genSpan(31, 31, 808, 31, 38, 815),
+							genSym(genSpan(31, 32, 809, 31, 35, 812), "cdr"),
+							genSym(genSpan(31, 36, 813, 31, 37, 814), "y"))))))),
+	genList(genSpan(37, 1, 964, 38, 17, 996),
+		genSym(genSpan(37, 2, 965, 37, 7, 970), "defun"),
+		genSym(genSpan(37, 8, 971, 37, 12, 975), "caar"),
+		genList(genSpan(37, 13, 976, 37, 16, 979),
+			genSym(genSpan(37, 14, 977, 37, 15, 978), "x")),
+		genList(genSpan(38, 3, 982, 38, 16, 995),
+			genSym(genSpan(38, 4, 983, 38, 7, 986), "car"),
+			genList(genSpan(38, 8, 987, 38, 15, 994),
+				genSym(genSpan(38, 9, 988, 38, 12, 991), "car"),
+				genSym(genSpan(38, 13, 992, 38, 14, 993), "x")))),
+	genList(genSpan(40, 1, 998, 41, 23, 1037),
+		genSym(genSpan(40, 2, 999, 40, 7, 1004), "defun"),
+		genSym(genSpan(40, 8, 1005, 40, 13, 1010), "cadar"),
+		genList(genSpan(40, 14, 1011, 40, 17, 1014),
+			genSym(genSpan(40, 15, 1012, 40, 16, 1013), "x")),
+		genList(genSpan(41, 3, 1017, 41, 22, 1036),
+			genSym(genSpan(41, 4, 1018, 41, 7, 1021), "car"),
+			genList(genSpan(41, 8, 1022, 41, 21, 1035),
+				genSym(genSpan(41, 9, 1023, 41, 12, 1026), "cdr"),
+				genList(genSpan(41, 13, 1027, 41, 20, 1034),
+					genSym(genSpan(41, 14, 1028, 41, 17, 1031), "car"),
+					genSym(genSpan(41, 18, 1032, 41, 19, 1033), "x"))))),
+	genList(genSpan(43, 1, 1039, 44, 17, 1071),
+		genSym(genSpan(43, 2, 1040, 43, 7, 1045), "defun"),
+		genSym(genSpan(43, 8, 1046, 43, 12, 1050), "cadr"),
+		genList(genSpan(43, 13, 1051, 43, 16, 1054),
+			genSym(genSpan(43, 14, 1052, 43, 15, 1053), "x")),
+		genList(genSpan(44, 3, 1057, 44, 16, 1070),
+			genSym(genSpan(44, 4, 1058, 44, 7, 1061), "car"),
+			genList(genSpan(44, 8, 1062, 44, 15, 1069),
+				genSym(genSpan(44, 9, 1063, 44, 12, 1066), "cdr"),
+				genSym(genSpan(44, 13, 1067, 44, 14, 1068), "x")))),
+	genList(genSpan(46, 1, 1073, 47, 23, 1112),
+		genSym(genSpan(46, 2, 1074, 46, 7, 1079), "defun"),
+		genSym(genSpan(46, 8, 1080, 46, 13, 1085), "caddr"),
+		genList(genSpan(46, 14, 1086, 46, 17, 1089),
+			genSym(genSpan(46, 15, 1087, 46, 16, 1088), "x")),
+		genList(genSpan(47, 3, 1092, 47, 22, 1111),
+			genSym(genSpan(47, 4, 1093, 47, 7, 1096), "car"),
+			genList(genSpan(47, 8, 1097, 47, 21, 1110),
+				genSym(genSpan(47, 9, 1098, 47, 12, 1101), "cdr"),
+				genList(genSpan(47, 13, 1102, 47, 20, 1109),
+					genSym(genSpan(47, 14, 1103, 47, 17, 1106), "cdr"),
+					genSym(genSpan(47, 18, 1107, 47, 19, 1108), "x"))))),
+	genList(genSpan(51, 1, 1134, 53, 34, 1223),
+		genSym(genSpan(51, 2, 1135, 51, 7, 1140), "defun"),
+		genSym(genSpan(51, 8, 1141, 51, 14, 1147), "assoc."),
+		genList(genSpan(51, 15, 1148, 51, 20, 1153),
+			genSym(genSpan(51, 16, 1149, 51, 17, 1150), "x"),
+			genSym(genSpan(51, 18, 1151, 51, 19, 1152), "y")),
+		genList(genSpan(52, 3, 1156, 53, 33, 1222),
+			genSym(genSpan(52, 4, 1157, 52, 8, 1161), "cond"),
+			genList(genSpan(52, 9, 1162, 52, 36, 1189),
+				genList(genSpan(52, 10, 1163, 52, 25, 1178),
+					genSym(genSpan(52, 11, 1164, 52, 13, 1166), "eq"),
+					genList(genSpan(52, 14, 1167, 52, 22, 1175),
+						genSym(genSpan(52, 15, 1168, 52, 19, 1172), "caar"),
+						genSym(genSpan(52, 20, 1173, 52, 21, 1174), "y")),
+					genSym(genSpan(52, 23, 1176, 52, 24, 1177), "x")),
+				genList(genSpan(52, 26, 1179, 52, 35, 1188),
+					genSym(genSpan(52, 27, 1180, 52, 32, 1185), "cadar"),
+					genSym(genSpan(52, 33, 1186, 52, 34, 1187), "y"))),
+			genList(genSpan(53, 9, 1198, 53, 32, 1221),
+				genList(genSpan(53, 10, 1199, 53, 12, 1201),
+					genSym(genSpan(53, 10, 1199, 53, 11, 1200), "quote"),
+					genSym(genSpan(53, 11, 1200, 53, 12, 1201), "t")),
+				genList(genSpan(53, 13, 1202, 53, 31, 1220),
+					genSym(genSpan(53, 14, 1203, 53, 20, 1209), "assoc."),
+					genSym(genSpan(53, 21, 1210, 53, 22, 1211), "x"),
+					genList(genSpan(53, 23, 1212, 53, 30, 1219),
+						genSym(genSpan(53, 24, 1213, 53, 27, 1216), "cdr"),
+						genSym(genSpan(53, 28, 1217, 53, 29, 1218), "y")))))),
+	genList(genSpan(55, 1, 1225, 78, 28, 2142),
+		genSym(genSpan(55, 2, 1226, 55, 7, 1231), "defun"),
+		genSym(genSpan(55, 8, 1232, 55, 13, 1237), "eval."),
+		genList(genSpan(55, 14, 1238, 55, 19, 1243),
+			genSym(genSpan(55, 15, 1239, 55, 16, 1240), "e"),
+			genSym(genSpan(55, 17, 1241, 55, 18, 1242), "a")),
+		genList(genSpan(56, 3, 1246, 78, 27, 2141),
+			genSym(genSpan(56, 4, 1247, 56, 8, 1251), "cond"),
+			genList(genSpan(57, 5, 1256, 57, 28, 1279),
+				genList(genSpan(57, 6, 1257, 57, 14, 1265),
+					genSym(genSpan(57, 7, 1258, 57, 11, 1262), "atom"),
+					genSym(genSpan(57, 12, 1263, 57, 13, 1264), "e")),
+				genList(genSpan(57, 15, 1266, 57, 27, 1278),
+					genSym(genSpan(57, 16, 1267, 57, 22, 1273), "assoc."),
+					genSym(genSpan(57, 23, 1274, 57, 24, 1275), "e"),
+					genSym(genSpan(57, 25, 1276, 57, 26, 1277), "a"))),
+			genList(genSpan(58, 5, 1284, 71, 24, 1893),
+				genList(genSpan(58, 6, 1285, 58, 20, 1299),
+					genSym(genSpan(58, 7, 1286, 58, 11, 1290), "atom"),
+					genList(genSpan(58, 12, 1291, 58, 19, 1298),
+						genSym(genSpan(58, 13, 1292, 58, 16, 1295), "car"),
+						genSym(genSpan(58, 17, 1296, 58, 18, 1297), "e"))),
+				genList(genSpan(59, 6, 1305, 71, 23, 1892),
+					genSym(genSpan(59, 7, 1306, 59, 11, 1310), "cond"),
+					genList(genSpan(60, 8, 1318, 60, 38, 1348),
+						genList(genSpan(60, 9, 1319, 60, 28, 1338),
+							genSym(genSpan(60, 10, 1320, 60, 12, 1322), "eq"),
+							genList(genSpan(60, 13, 1323, 60, 20, 1330),
+								genSym(genSpan(60, 14, 1324, 60, 17, 1327), "car"),
+								genSym(genSpan(60, 18, 1328, 60, 19, 1329), "e")),
+							genList(genSpan(60, 21, 1331, 60, 27, 1337),
+								genSym(genSpan(60, 21, 1331, 60, 22, 1332), "quote"),
+								genSym(genSpan(60, 22, 1332, 60, 27, 1337), "quote"))),
+						genList(genSpan(60, 29, 1339, 60, 37, 1347),
+							genSym(genSpan(60, 30, 1340, 60, 34, 1344), "cadr"),
+							genSym(genSpan(60, 35, 1345, 60, 36, 1346), "e"))),
+					genList(genSpan(61, 8, 1356, 61, 57, 1405),
+						genList(genSpan(61, 9, 1357, 61, 27, 1375),
+							genSym(genSpan(61, 10, 1358, 61, 12, 1360), "eq"),
+							genList(genSpan(61, 13, 1361, 61, 20, 1368),
+								genSym(genSpan(61, 14, 1362, 61, 17, 1365), "car"),
+								genSym(genSpan(61, 18, 1366, 61, 19, 1367), "e")),
+							genList(genSpan(61, 21, 1369, 61, 26, 1374),
+								genSym(genSpan(61, 21, 1369, 61, 22, 1370), "quote"),
+								genSym(genSpan(61, 22, 1370, 61, 26, 1374), "atom"))),
+						genList(genSpan(61, 29, 1377, 61, 56, 1404),
+							genSym(genSpan(61, 30, 1378, 61, 34, 1382), "atom"),
+							genList(genSpan(61, 37, 1385, 61, 55, 1403),
+								genSym(genSpan(61, 38, 1386, 61, 43, 1391), "eval."),
+								genList(genSpan(61, 44, 1392, 61, 52, 1400),
+									genSym(genSpan(61, 45, 1393, 61, 49, 1397), "cadr"),
+									genSym(genSpan(61, 50, 1398, 61, 51, 1399), "e")),
+								genSym(genSpan(61, 53, 1401, 61, 54, 1402), "a")))),
+					genList(genSpan(62, 8, 1413, 63, 58, 1518),
+						genList(genSpan(62, 9, 1414, 62, 25, 1430),
+							genSym(genSpan(62, 10, 1415, 62, 12, 1417), "eq"),
+							genList(genSpan(62, 13, 1418, 62, 20, 1425),
+								genSym(genSpan(62, 14, 1419, 62, 17, 1422), "car"),
+								genSym(genSpan(62, 18, 1423, 62, 19, 1424), "e")),
+							genList(genSpan(62, 21, 1426, 62, 24, 1429),
+								genSym(genSpan(62, 21, 1426, 62, 22, 1427), "quote"),
+								genSym(genSpan(62, 22, 1427, 62, 24, 1429), "eq"))),
+						genList(genSpan(62, 29, 1434, 63, 57, 1517),
+							genSym(genSpan(62, 30, 1435, 62, 32, 1437), "eq"),
+							genList(genSpan(62, 37, 1442, 62, 55, 1460),
+								genSym(genSpan(62, 38, 1443, 62, 43, 1448), "eval."),
+								genList(genSpan(62, 44, 1449, 62, 52, 1457),
+									genSym(genSpan(62, 45, 1450, 62, 49, 1454), "cadr"),
+									genSym(genSpan(62, 50, 1455, 62, 51, 1456), "e")),
+								genSym(genSpan(62, 53, 1458, 62, 54, 1459), "a")),
+							genList(genSpan(63, 37, 1497, 63, 56, 1516),
+								genSym(genSpan(63, 38, 1498, 63, 43, 1503), "eval."),
+								genList(genSpan(63, 44, 1504, 63, 53, 1513),
+									genSym(genSpan(63, 45, 1505, 63, 50, 1510), "caddr"),
+									genSym(genSpan(63, 51, 1511, 63, 52, 1512), "e")),
+								genSym(genSpan(63, 54, 1514, 63, 55, 1515), "a")))),
+					genList(genSpan(64, 8, 1526, 64, 57, 1575),
+						genList(genSpan(64, 9, 1527, 64, 26, 1544),
+							genSym(genSpan(64, 10, 1528, 64, 12, 1530), "eq"),
+							genList(genSpan(64, 13, 1531, 64, 20, 1538),
+								genSym(genSpan(64, 14, 1532, 64, 17, 1535), "car"),
+								genSym(genSpan(64, 18, 1536, 64, 19, 1537), "e")),
+							genList(genSpan(64, 21, 1539, 64, 25, 1543),
+								genSym(genSpan(64, 21, 1539, 64, 22, 1540), "quote"),
+								genSym(genSpan(64, 22, 1540, 64, 25, 1543), "car"))),
+						genList(genSpan(64, 29, 1547, 64, 56, 1574),
+							genSym(genSpan(64, 30, 1548, 64, 33, 1551), "car"),
+							genList(genSpan(64, 37, 1555, 64, 55, 1573),
+								genSym(genSpan(64, 38, 1556, 64, 43, 1561), "eval."),
+								genList(genSpan(64, 44, 1562, 64, 52, 1570),
+									genSym(genSpan(64, 45, 1563, 64, 49, 1567), "cadr"),
+									genSym(genSpan(64, 50, 1568, 64, 51, 1569), "e")),
+								genSym(genSpan(64, 53, 1571, 64, 54, 1572), "a")))),
+					genList(genSpan(65, 8, 1583, 65, 57, 1632),
+						genList(genSpan(65, 9, 1584, 65, 26, 1601),
+							genSym(genSpan(65, 10, 1585, 65, 12, 1587), "eq"),
+							genList(genSpan(65, 13, 1588, 65, 20, 1595),
+								genSym(genSpan(65, 14, 1589, 65, 17, 1592), "car"),
+								genSym(genSpan(65, 18, 1593, 65, 19, 1594), "e")),
+							genList(genSpan(65, 21, 1596, 65, 25, 1600),
+								genSym(genSpan(65, 21, 1596, 65, 22, 1597), "quote"),
+								genSym(genSpan(65, 22, 1597, 65, 25, 1600), "cdr"))),
+						genList(genSpan(65, 29, 1604, 65, 56, 1631),
+							genSym(genSpan(65, 30, 1605, 65, 33, 1608), "cdr"),
+							genList(genSpan(65, 37, 1612, 65, 55, 1630),
+								genSym(genSpan(65, 38, 1613, 65, 43, 1618), "eval."),
+								genList(genSpan(65, 44, 1619, 65, 52, 1627),
+									genSym(genSpan(65, 45, 1620, 65, 49, 1624), "cadr"),
+									genSym(genSpan(65, 50, 1625, 65, 51, 1626), "e")),
+								genSym(genSpan(65, 53, 1628, 65, 54, 1629), "a")))),
+					genList(genSpan(66, 8, 1640, 67, 58, 1745),
+						genList(genSpan(66, 9, 1641, 66, 27, 1659),
+							genSym(genSpan(66, 10, 1642, 66, 12, 1644), "eq"),
+							genList(genSpan(66, 13, 1645, 66, 20, 1652),
+								genSym(genSpan(66, 14, 1646, 66, 17, 1649), "car"),
+								genSym(genSpan(66, 18, 1650, 66, 19, 1651), "e")),
+							genList(genSpan(66, 21, 1653, 66, 26, 1658),
+								genSym(genSpan(66, 21, 1653, 66, 22, 1654), "quote"),
+								genSym(genSpan(66, 22, 1654, 66, 26, 1658), "cons"))),
+						genList(genSpan(66, 29, 1661, 67, 57, 1744),
+							genSym(genSpan(66, 30, 1662, 66, 34, 1666), "cons"),
+							genList(genSpan(66, 37, 1669, 66, 55, 1687),
+								genSym(genSpan(66, 38, 1670, 66, 43, 1675), "eval."),
+								genList(genSpan(66, 44, 1676, 66, 52, 1684),
+									genSym(genSpan(66, 45, 1677, 66, 49, 1681), "cadr"),
+									genSym(genSpan(66, 50, 1682, 66, 51, 1683), "e")),
+								genSym(genSpan(66, 53, 1685, 66, 54, 1686), "a")),
+							genList(genSpan(67, 37, 1724, 67, 56, 1743),
+								genSym(genSpan(67, 38, 1725, 67, 43, 1730), "eval."),
+								genList(genSpan(67, 44, 1731, 67, 53, 1740),
+									genSym(genSpan(67, 45, 1732, 67, 50, 1737), "caddr"),
+									genSym(genSpan(67, 51, 1738, 67, 52, 1739), "e")),
+								genSym(genSpan(67, 54, 1741, 67, 55, 1742), "a")))),
+					genList(genSpan(68, 8, 1753, 68, 48, 1793),
+						genList(genSpan(68, 9, 1754, 68, 27, 1772),
+							genSym(genSpan(68, 10, 1755, 68, 12, 1757), "eq"),
+							genList(genSpan(68, 13, 1758, 68, 20, 1765),
+								genSym(genSpan(68, 14, 1759, 68, 17, 1762), "car"),
+								genSym(genSpan(68, 18, 1763, 68, 19, 1764), "e")),
+							genList(genSpan(68, 21, 1766, 68, 26, 1771),
+								genSym(genSpan(68, 21, 1766, 68, 22, 1767), "quote"),
+								genSym(genSpan(68, 22, 1767, 68, 26, 1771), "cond"))),
+						genList(genSpan(68, 29, 1774, 68, 47, 1792),
+							genSym(genSpan(68, 30, 1775, 68, 36, 1781), "evcon."),
+							genList(genSpan(68, 37, 1782, 68, 44, 1789),
+								genSym(genSpan(68, 38, 1783, 68, 41, 1786), "cdr"),
+								genSym(genSpan(68, 42, 1787, 68, 43, 1788), "e")),
+							genSym(genSpan(68, 45, 1790, 68, 46, 1791), "a"))),
+					genList(genSpan(69, 8, 1801, 71, 22, 1891),
+						genList(genSpan(69, 9, 1802, 69, 11, 1804),
+							genSym(genSpan(69, 9, 1802, 69, 10, 1803), "quote"),
+							genSym(genSpan(69, 10, 1803, 69, 11, 1804), "t")),
+						genList(genSpan(69, 12, 1805, 71, 21, 1890),
+							genSym(genSpan(69, 13, 1806, 69, 18, 1811), "eval."),
+							genList(genSpan(69, 19, 1812, 70, 33, 1869),
+								genSym(genSpan(69, 20, 1813, 69, 24, 1817), "cons"),
+								genList(genSpan(69, 25, 1818, 69, 43, 1836),
+									genSym(genSpan(69, 26, 1819, 69, 32, 1825), "assoc."),
+									genList(genSpan(69, 33, 1826, 69, 40, 1833),
+										genSym(genSpan(69, 34, 1827, 69, 37, 1830), "car"),
+										genSym(genSpan(69, 38, 1831, 69, 39, 1832), "e")),
+									genSym(genSpan(69, 41, 1834, 69, 42, 1835), "a")),
+								genList(genSpan(70, 25, 1861, 70, 32, 1868),
+									genSym(genSpan(70, 26, 1862, 70, 29, 1865), "cdr"),
+									genSym(genSpan(70, 30, 1866, 70, 31, 1867), "e"))),
+							genSym(genSpan(71, 19, 1888, 71, 20, 1889), "a"))))),
+			genList(genSpan(72, 5, 1898, 74, 49, 2006),
+				genList(genSpan(72, 6, 1899, 72, 26, 1919),
+					genSym(genSpan(72, 7, 1900, 72, 9, 1902), "eq"),
+					genList(genSpan(72, 10, 1903, 72, 18, 1911),
+						genSym(genSpan(72, 11, 1904, 72, 15, 1908), "caar"),
+						genSym(genSpan(72, 16, 1909, 72, 17, 1910), "e")),
+					genList(genSpan(72, 19, 1912, 72, 25, 1918),
+						genSym(genSpan(72, 19, 1912, 72, 20, 1913), "quote"),
+						genSym(genSpan(72, 20, 1913, 72, 25, 1918), "label"))),
+				genList(genSpan(73, 6, 1925, 74, 48, 2005),
+					genSym(genSpan(73, 7, 1926, 73, 12, 1931), "eval."),
+					genList(genSpan(73, 13, 1932, 73, 38, 1957),
+						genSym(genSpan(73, 14, 1933, 73, 18, 1937), "cons"),
+						genList(genSpan(73, 19, 1938, 73, 29, 1948),
+							genSym(genSpan(73, 20, 1939, 73, 26, 1945), "caddar"),
+							genSym(genSpan(73, 27, 1946, 73, 28, 1947), "e")),
+						genList(genSpan(73, 30, 1949, 73, 37, 1956),
+							genSym(genSpan(73, 31, 1950, 73, 34, 1953), "cdr"),
+							genSym(genSpan(73, 35, 1954, 73, 36, 1955), "e"))),
+					genList(genSpan(74, 13, 1970, 74, 47, 2004),
+						genSym(genSpan(74, 14, 1971, 74, 18, 1975), "cons"),
+						genList(genSpan(74, 19, 1976, 74, 44, 2001),
+							genSym(genSpan(74, 20, 1977, 74, 25, 1982), "list."),
+							genList(genSpan(74, 26, 1983, 74, 35, 1992),
+								genSym(genSpan(74, 27, 1984, 74, 32, 1989), "cadar"),
+								genSym(genSpan(74, 33, 1990, 74, 34, 1991), "e")),
+							genList(genSpan(74, 36, 1993, 74, 43, 2000),
+								genSym(genSpan(74, 37, 1994, 74, 40, 1997), "car"),
+								genSym(genSpan(74, 41, 1998, 74, 42, 1999), "e"))),
+						genSym(genSpan(74, 45, 2002, 74, 46, 2003), "a")))),
+			genList(genSpan(75, 5, 2011, 78, 26, 2140),
+				genList(genSpan(75, 6, 2012, 75, 27, 2033),
+					genSym(genSpan(75, 7, 2013, 75, 9, 2015), "eq"),
+					genList(genSpan(75, 10, 2016, 75, 18, 2024),
+						genSym(genSpan(75, 11, 2017, 75, 15, 2021), "caar"),
+						genSym(genSpan(75, 16, 2022, 75, 17, 2023), "e")),
+					genList(genSpan(75, 19, 2025, 75, 26, 2032),
+						genSym(genSpan(75, 19, 2025, 75, 20, 2026), "quote"),
+						genSym(genSpan(75, 20, 2026, 75, 26, 2032), "lambda"))),
+				genList(genSpan(76, 6, 2039, 78, 25, 2139),
+					genSym(genSpan(76, 7, 2040, 76, 12, 2045), "eval."),
+					genList(genSpan(76, 13, 2046, 76, 23, 2056),
+						genSym(genSpan(76, 14, 2047, 76, 20, 2053), "caddar"),
+						genSym(genSpan(76, 21, 2054, 76, 22, 2055), "e")),
+					genList(genSpan(77, 13, 2069, 78, 24, 2138),
+						genSym(genSpan(77, 14, 2070, 77, 21, 2077), "append."),
+						genList(genSpan(77, 22, 2078, 77, 58, 2114),
+							genSym(genSpan(77, 23, 2079, 77, 28, 2084), "pair."),
+							genList(genSpan(77, 29, 2085, 77, 38, 2094),
+								genSym(genSpan(77, 30, 2086, 77, 35, 2091), "cadar"),
+								genSym(genSpan(77, 36, 2092, 77, 37, 2093), "e")),
+							genList(genSpan(77, 39, 2095, 77, 57, 2113),
+								genSym(genSpan(77, 40, 2096, 77, 46, 2102), "evlis."),
+								genList(genSpan(77, 47, 2103, 77, 54, 2110),
+									genSym(genSpan(77, 48, 2104, 77, 51, 2107), "cdr"),
+									genSym(genSpan(77, 52, 2108, 77, 53, 2109), "e")),
+								genSym(genSpan(77, 55, 2111, 77, 56, 2112), "a"))),
+						genSym(genSpan(78, 22, 2136, 78, 23, 2137), "a")))))),
+	genList(genSpan(80, 1, 2144, 83, 34, 2255),
+		genSym(genSpan(80, 2, 2145, 80, 7, 2150), "defun"),
+		genSym(genSpan(80, 8, 2151, 80, 14, 2157), "evcon."),
+		genList(genSpan(80, 15, 2158, 80, 20, 2163),
+			genSym(genSpan(80, 16, 2159, 80, 17, 2160), "c"),
+			genSym(genSpan(80, 18, 2161, 80, 19, 2162), "a")),
+		genList(genSpan(81, 3, 2166, 83, 33, 2254),
+			genSym(genSpan(81, 4, 2167, 81, 8, 2171), "cond"),
+			genList(genSpan(81, 9, 2172, 82, 30, 2221),
+				genList(genSpan(81, 10, 2173, 81, 28, 2191),
+					genSym(genSpan(81, 11, 2174, 81, 16, 2179), "eval."),
+					genList(genSpan(81, 17, 2180, 81, 25, 2188),
+						genSym(genSpan(81, 18, 2181, 81, 22, 2185), "caar"),
+						genSym(genSpan(81, 23, 2186, 81, 24, 2187), "c")),
+					genSym(genSpan(81, 26, 2189, 81, 27, 2190), "a")),
+				genList(genSpan(82, 10, 2201, 82, 29, 2220),
+					genSym(genSpan(82, 11, 2202, 82, 16, 2207), "eval."),
+					genList(genSpan(82, 17, 2208, 82, 26, 2217),
+						genSym(genSpan(82, 18, 2209, 82, 23, 2214), "cadar"),
+						genSym(genSpan(82, 24, 2215, 82, 25, 2216), "c")),
+					genSym(genSpan(82, 27, 2218, 82, 28, 2219), "a"))),
+			genList(genSpan(83, 9, 2230, 83, 32, 2253),
+				genList(genSpan(83, 10, 2231, 83, 12, 2233),
+					genSym(genSpan(83, 10, 2231, 83, 11, 2232), "quote"),
+					genSym(genSpan(83, 11, 2232, 83, 12, 2233), "t")),
+				genList(genSpan(83, 13, 2234, 83, 31, 2252),
+					genSym(genSpan(83, 14, 2235, 83, 20, 2241), "evcon."),
+					genList(genSpan(83, 21, 2242, 83, 28, 2249),
+						genSym(genSpan(83, 22, 2243, 83, 25, 2246), "cdr"),
+						genSym(genSpan(83, 26, 2247, 83, 27, 2248), "c")),
+					genSym(genSpan(83, 29, 2250, 83, 30, 2251), "a"))))),
+	genList(genSpan(85, 1, 2257, 88, 41, 2378),
+		genSym(genSpan(85, 2, 2258, 85, 7, 2263), "defun"),
+		genSym(genSpan(85, 8, 2264, 85, 14, 2270), "evlis."),
+		genList(genSpan(85, 15, 2271, 85, 20, 2276),
+			genSym(genSpan(85, 16, 2272, 85, 17, 2273), "m"),
+			genSym(genSpan(85, 18, 2274, 85, 19, 2275), "a")),
+		genList(genSpan(86, 3, 2279, 88, 40, 2377),
+			genSym(genSpan(86, 4, 2280, 86, 8, 2284), "cond"),
+			genList(genSpan(86, 9, 2285, 86, 24, 2300),
+				genList(genSpan(86, 10, 2286, 86, 19, 2295),
+					genSym(genSpan(86, 11, 2287, 86, 16, 2292), "null."),
+					genSym(genSpan(86, 17, 2293, 86, 18, 2294), "m")),
+				genList(genSpan(86, 20, 2296, 86, 23, 2299),
+					genSym(genSpan(86, 20, 2296, 86, 21, 2297), "quote"),
+					genList(genSpan(86, 21, 2297, 86, 23, 2299)))),
+			genList(genSpan(87, 9, 2309, 88, 39, 2376),
+				genList(genSpan(87, 10, 2310, 87, 12, 2312),
+					genSym(genSpan(87, 10, 2310, 87, 11, 2311), "quote"),
+					genSym(genSpan(87, 11, 2311, 87, 12, 2312), "t")),
+				genList(genSpan(87, 13, 2313, 88, 38, 2375),
+					genSym(genSpan(87, 14, 2314, 87, 18, 2318), "cons"),
+					genList(genSpan(87, 19, 2319, 87, 37, 2337),
+						genSym(genSpan(87, 20, 2320, 87, 25, 2325), "eval."),
+						genList(genSpan(87, 27, 2327, 87, 34, 2334),
+							genSym(genSpan(87, 28, 2328, 87, 31, 2331), "car"),
+							genSym(genSpan(87, 32, 2332, 87, 33, 2333), "m")),
+						genSym(genSpan(87, 35, 2335, 87, 36, 2336), "a")),
+					genList(genSpan(88, 19, 2356, 88, 37, 2374),
+						genSym(genSpan(88, 20, 2357, 88, 26, 2363), "evlis."),
+						genList(genSpan(88, 27, 2364, 88, 34, 2371),
+							genSym(genSpan(88, 28, 2365, 88, 31, 2368), "cdr"),
+							genSym(genSpan(88, 32, 2369, 88, 33, 2370), "m")),
+						genSym(genSpan(88, 35, 2372, 88, 36, 2373), "a")))))),
+}
@@ -0,0 +1,293 @@
+package interp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/reflechant/minimal-lisp/core"
+	"github.com/reflechant/minimal-lisp/parser"
+)
+
+// Reload describes the definitions a changed watched file added, changed or removed
+type Reload struct {
+	File                    string
+	Added, Changed, Removed []string
+}
+
+// String returns e.g. "lib.lisp: added f, changed g, removed h"
+func (r Reload) String() string {
+	parts := []string{}
+	for _, kind := range []struct {
+		verb  string
+		names []string
+	}{{"added", r.Added}, {"changed", r.Changed}, {"removed", r.Removed}} {
+		if len(kind.names) > 0 {
+			parts = append(parts, kind.verb+" "+strings.Join(kind.names, ", "))
+		}
+	}
+
+	return r.File + ": " + strings.Join(parts, ", ")
+}
+
+// watchedFile is a file reloaded when it changes
+type watchedFile struct {
+	path    string
+	modTime time.Time
+	size    int64
+	// defs maps the names defined in the file, qualified with their packages,
+	// to the text of their definitions
+	defs map[string]string
+}
+
+// WatchFile loads the file at fpath like LoadFileAtomic and
+// remembers its definitions so that Poll can reload them when it changes.
+func (it *Interpreter) WatchFile(fpath string) error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	return it.watchFile(path.Clean(fpath))
+}
+
+func (it *Interpreter) watchFile(fpath string) error {
+	if _, ok := it.watched[fpath]; ok {
+		return nil
+	}
+	info, err := os.Stat(fpath)
+	if err != nil {
+		return err
+	}
+	if err := it.loadAtomic(fpath); err != nil {
+		return err
+	}
+	forms, err := it.parseFile(fpath)
+	if err != nil {
+		return err
+	}
+
+	it.watched[fpath] = &watchedFile{
+		path:    fpath,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		defs:    definitions(forms),
+	}
+
+	return nil
+}
+
+// watchFileFn is the `watch-file` builtin
+func (it *Interpreter) watchFileFn(scope core.Scope, args ...core.SExpr) (core.SExpr, error) {
+	name, err := it.nameArg("watch-file", scope, args)
+	if err != nil {
+		return nil, err
+	}
+
+	fpath := name
+	if !filepath.IsAbs(fpath) {
+		fpath = filepath.Join(it.currentDir(), fpath)
+	}
+	if err := it.watchFile(fpath); err != nil {
+		return nil, fmt.Errorf("watch-file: %w", err)
+	}
+
+	return core.Void, nil
+}
+
+// Poll reloads the watched files which changed since they were loaded.
+// Only the definitions which were added or changed are evaluated again
+// (along with `defpackage` and `in-package` forms), definitions removed
+// from a file are unbound. If a file fails to reload, its definitions
+// stay as they were and the error is returned with the reloads that succeeded.
+func (it *Interpreter) Poll() ([]Reload, error) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	reloads := []Reload{}
+	errs := []error{}
+	for _, fpath := range slices.Sorted(maps.Keys(it.watched)) {
+		r, changed, err := it.reload(it.watched[fpath])
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if changed {
+			reloads = append(reloads, r)
+		}
+	}
+
+	return reloads, errors.Join(errs...)
+}
+
+// Watch polls the watched files every interval until ctx is done
+// and reports what was reloaded.
+func (it *Interpreter) Watch(ctx context.Context, interval time.Duration, report func([]Reload, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloads, err := it.Poll()
+			if len(reloads) > 0 || err != nil {
+				report(reloads, err)
+			}
+		}
+	}
+}
+
+// reload evaluates the changed definitions of f, changed is false if there were none
+func (it *Interpreter) reload(f *watchedFile) (r Reload, changed bool, err error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return Reload{}, false, err
+	}
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return Reload{}, false, nil
+	}
+	// don't report a broken file again until it changes
+	f.modTime, f.size = info.ModTime(), info.Size()
+
+	forms, err := it.parseFile(f.path)
+	if err != nil {
+		return Reload{}, false, err
+	}
+	defs := definitions(forms)
+
+	r = Reload{File: f.path}
+	for name, text := range defs {
+		old, ok := f.defs[name]
+		switch {
+		case !ok:
+			r.Added = append(r.Added, name)
+		case old != text:
+			r.Changed = append(r.Changed, name)
+		}
+	}
+	for name := range f.defs {
+		if _, ok := defs[name]; !ok {
+			r.Removed = append(r.Removed, name)
+		}
+	}
+	if len(r.Added)+len(r.Changed)+len(r.Removed) == 0 {
+		return Reload{}, false, nil
+	}
+	slices.Sort(r.Added)
+	slices.Sort(r.Changed)
+	slices.Sort(r.Removed)
+
+	scope := it.callScope()
+	pkg := scope.Package()
+	staging := scope.NewLayer()
+	errs := []error{}
+	// the forms are read starting in the user package
+	if err := inPackage(scope, core.UserPackage); err != nil {
+		errs = append(errs, err)
+	}
+	for _, form := range forms {
+		reeval := isPackageForm(form.expr)
+		if name, ok := core.DefinedName(form.expr); ok {
+			key := core.QualifiedName(form.pkg, name)
+			reeval = slices.Contains(r.Added, key) || slices.Contains(r.Changed, key)
+		}
+		if !reeval {
+			continue
+		}
+		if _, err := form.expr.Eval(staging); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	// the file may switch packages, go back to the one we were in
+	if err := inPackage(scope, pkg); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return Reload{}, false, &core.LoadError{SrcName: f.path, Errs: errs}
+	}
+
+	scope.Commit(staging)
+	for _, name := range r.Removed {
+		it.scope.Unbind(name)
+	}
+	f.defs = defs
+
+	return r, true, nil
+}
+
+// inPackage switches the current package
+func inPackage(scope core.Scope, pkg string) error {
+	form := core.FromSlice([]core.SExpr{core.NewSymbol("", 0, 0, "in-package"), core.NewSymbol("", 0, 0, pkg)})
+	_, err := form.Eval(scope)
+	return err
+}
+
+// form is a top-level form with the package it is in
+type form struct {
+	expr core.SExpr
+	pkg  string
+}
+
+// parseFile returns the top-level forms of a file,
+// its text is kept for error messages in the reloaded forms
+func (it *Interpreter) parseFile(fpath string) ([]form, error) {
+	file, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	exprs, err := parser.Parse(fpath, 0, it.keepSource(fpath, file))
+	if err != nil {
+		return nil, err
+	}
+
+	forms := []form{}
+	pkg := core.UserPackage
+	for _, e := range exprs {
+		forms = append(forms, form{expr: e, pkg: pkg})
+		if l, ok := e.(core.List); ok && isPackageForm(e) && headName(l) == "in-package" {
+			if name, ok := l.Second().(core.List); ok {
+				if sym, ok := name.First().(core.Symbol); ok {
+					pkg = sym.Name()
+				}
+			}
+		}
+	}
+
+	return forms, nil
+}
+
+// definitions maps the names defined by forms to the text of their definitions
+func definitions(forms []form) map[string]string {
+	defs := map[string]string{}
+	for _, f := range forms {
+		if name, ok := core.DefinedName(f.expr); ok {
+			defs[core.QualifiedName(f.pkg, name)] = f.expr.String()
+		}
+	}
+
+	return defs
+}
+
+// isPackageForm reports whether e is a defpackage or in-package form
+func isPackageForm(e core.SExpr) bool {
+	l, ok := e.(core.List)
+	return ok && (headName(l) == "defpackage" || headName(l) == "in-package")
+}
+
+// headName returns the name of the symbol a list starts with
+func headName(l core.List) string {
+	head, ok := l.First().(core.Symbol)
+	if !ok {
+		return ""
+	}
+
+	return head.Name()
+}
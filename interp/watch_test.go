@@ -0,0 +1,174 @@
+package interp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reflechant/minimal-lisp/core"
+)
+
+// editFile rewrites a watched file and moves its modification time forward,
+// so the change is seen even if the size stays the same
+func editFile(t *testing.T, fpath, content string) {
+	t.Helper()
+	info, err := os.Stat(fpath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(fpath, []byte(content), 0o644))
+	later := info.ModTime().Add(time.Second)
+	require.NoError(t, os.Chtimes(fpath, later, later))
+}
+
+func TestWatchFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"lib.lisp": "(defun f () 'f1)\n(defun g () 'g1)\n(defun h () 'h1)\n",
+	})
+	fpath := filepath.Join(dir, "lib.lisp")
+
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+	require.NoError(t, it.WatchFile(fpath))
+
+	reloads, err := it.Poll()
+	require.NoError(t, err)
+	assert.Empty(t, reloads, "nothing changed yet")
+
+	editFile(t, fpath, "(defun f () 'f1)\n(defun g () 'g2)\n(defun k () 'k1)\n")
+	reloads, err = it.Poll()
+	require.NoError(t, err)
+	require.Len(t, reloads, 1)
+	assert.Equal(t, Reload{File: fpath, Added: []string{"k"}, Changed: []string{"g"}, Removed: []string{"h"}}, reloads[0])
+
+	result, err := it.EvalString("(cons (f) (cons (g) (cons (k) '())))")
+	require.NoError(t, err)
+	assert.Equal(t, "(f1 g2 k1)", result.String())
+	_, ok := it.Lookup("h")
+	assert.False(t, ok, "removed definitions are unbound")
+
+	reloads, err = it.Poll()
+	require.NoError(t, err)
+	assert.Empty(t, reloads, "the change is reloaded once")
+}
+
+func TestWatchFileKeepsDefinitionsOnError(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"lib.lisp": "(defun f () 'f1)\n(defun g () 'g1)\n",
+	})
+	fpath := filepath.Join(dir, "lib.lisp")
+
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+	require.NoError(t, it.WatchFile(fpath))
+
+	editFile(t, fpath, "(defun f () 'f2)\n(defun g () 'g2)\n(defun k \"x\" 'k)\n")
+	reloads, err := it.Poll()
+	assert.Empty(t, reloads)
+	var loadErr *core.LoadError
+	require.ErrorAs(t, err, &loadErr)
+	assert.Equal(t, fpath, loadErr.SrcName)
+
+	result, err := it.EvalString("(cons (f) (cons (g) '()))")
+	require.NoError(t, err)
+	assert.Equal(t, "(f1 g1)", result.String(), "no definition of a broken file is reloaded")
+
+	// once fixed, the definitions are reloaded against the ones last loaded
+	editFile(t, fpath, "(defun f () 'f2)\n(defun g () 'g1)\n")
+	reloads, err = it.Poll()
+	require.NoError(t, err)
+	require.Len(t, reloads, 1)
+	assert.Equal(t, []string{"f"}, reloads[0].Changed)
+}
+
+func TestReloadErrorShowsNewText(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"lib.lisp": "(defun f () 'f1)\n"})
+	fpath := filepath.Join(dir, "lib.lisp")
+
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+	require.NoError(t, it.WatchFile(fpath))
+
+	editFile(t, fpath, "(defun f () 'f1)\n(defun g \"x\" 'g)\n")
+	_, err = it.Poll()
+	require.Error(t, err)
+	assert.Contains(t, it.FormatError(err, false), "2 | (defun g \"x\" 'g)")
+}
+
+func TestResetKeepsWatches(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"lib.lisp": "(defun f () (cons x '()))\n"})
+	fpath := filepath.Join(dir, "lib.lisp")
+
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+	it.Define("x", core.NewSymbol("", 0, 0, "a"))
+	require.NoError(t, it.WatchFile(fpath))
+	_, err = it.EvalString("(defun g () 'g)")
+	require.NoError(t, err)
+
+	require.NoError(t, it.Reset())
+	_, ok := it.Lookup("g")
+	assert.False(t, ok, "definitions are dropped")
+	result, err := it.EvalString("(f)")
+	require.NoError(t, err, "watched files are loaded again and Define bindings kept")
+	assert.Equal(t, "(a)", result.String())
+
+	// changes are reloaded into the new scope
+	editFile(t, fpath, "(defun f () 'f2)\n")
+	reloads, err := it.Poll()
+	require.NoError(t, err)
+	require.Len(t, reloads, 1)
+	result, err = core.FromSlice([]core.SExpr{core.NewSymbol("", 0, 0, "f")}).Eval(it.Scope())
+	require.NoError(t, err)
+	assert.Equal(t, "f2", result.String())
+}
+
+func TestWatchFilePackages(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"lib.lisp": "(defpackage lib (f))\n(in-package lib)\n(defun f () 'f1)\n(in-package user)\n",
+	})
+	fpath := filepath.Join(dir, "lib.lisp")
+
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+	require.NoError(t, it.WatchFile(fpath))
+
+	editFile(t, fpath, "(defpackage lib (f))\n(in-package lib)\n(defun f () 'f2)\n")
+	reloads, err := it.Poll()
+	require.NoError(t, err)
+	require.Len(t, reloads, 1)
+	assert.Equal(t, []string{"lib:f"}, reloads[0].Changed)
+
+	result, err := it.EvalString("(lib:f)")
+	require.NoError(t, err)
+	assert.Equal(t, "f2", result.String())
+	assert.Equal(t, core.UserPackage, it.Scope().Package(), "reloading doesn't switch the current package")
+}
+
+func TestWatchFileBuiltin(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.lisp": "(watch-file \"lib.lisp\")\n",
+		"lib.lisp":  "(defun f () 'f1)\n",
+	})
+
+	it, err := New(WithPrelude(false))
+	require.NoError(t, err)
+	require.NoError(t, it.LoadFile(filepath.Join(dir, "main.lisp")))
+	result, err := it.EvalString("(f)")
+	require.NoError(t, err)
+	assert.Equal(t, "f1", result.String())
+
+	// the path is relative to the file calling watch-file
+	editFile(t, filepath.Join(dir, "lib.lisp"), "(defun f () 'f2)\n")
+	reloads, err := it.Poll()
+	require.NoError(t, err)
+	require.Len(t, reloads, 1)
+	assert.Equal(t, []string{"f"}, reloads[0].Changed)
+}
+
+func TestReloadString(t *testing.T) {
+	r := Reload{File: "lib.lisp", Added: []string{"a", "b"}, Removed: []string{"c"}}
+	assert.Equal(t, "lib.lisp: added a, b, removed c", r.String())
+}
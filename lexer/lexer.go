@@ -2,27 +2,54 @@ package lexer
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
-type Error struct {
-	srcName string
-	line    uint
-	pos     uint
-	msg     string
+// LexError is returned when the input contains something that is not a valid token
+type LexError struct {
+	SrcName string
+	Line    uint
+	Col     uint
+	Offset  uint
+	Msg     string
+	// Incomplete is true if the input ended inside a token,
+	// more input (e.g. the next REPL line) may complete it
+	Incomplete bool
 }
 
-func (e Error) Error() string {
-	return fmt.Sprintf("%s:%d:%d: lex error: %s", e.srcName, e.line, e.pos, e.msg)
+func (e LexError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: lex error: %s", e.SrcName, e.Line, e.Col, e.Msg)
 }
 
-type Token struct {
-	Typ  TokenType
+// Unwrap returns io.ErrUnexpectedEOF for incomplete input
+func (e LexError) Unwrap() error {
+	if e.Incomplete {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// Position is a location in the input
+type Position struct {
+	// Line starts from 1
 	Line uint
-	Pos  uint
+	// Col is counted in runes starting from 1
+	Col uint
+	// Offset is counted in bytes from the start of the input
+	Offset uint
+}
+
+type Token struct {
+	Typ TokenType
+	// Start is the position of the first character of the token
+	Start Position
+	// End is the position right after the last character of the token
+	End  Position
 	Text string
 }
 
@@ -33,117 +60,283 @@ const (
 	LParen
 	RParen
 	Quote
+	// String is a string literal, its Text is the unescaped value
+	String
+	// DatumComment is `#;` which comments out the following expression
+	DatumComment
 )
 
-// Tokenize splits the input into recognized tokens and returns them in order.
+// Lexer reads tokens from the input one at a time.
+// Comments are skipped: `;` to the end of the line,
+// (nested) block comments `#| ... |#` and a shebang line `#!`
+// at the very start of the input, so scripts can be executable.
+type Lexer struct {
+	rdr     *bufio.Reader
+	srcName string
+	pos     Position // position of the next rune
+}
+
+// New returns a lexer reading from input.
 // srcName is used in error messages (file path, "repl", etc.).
 // lineOffset is added to every line number, enabling the REPL to report
 // cumulative line numbers across multiple inputs.
+func New(srcName string, lineOffset uint, input io.Reader) *Lexer {
+	return &Lexer{
+		rdr:     bufio.NewReader(input),
+		srcName: srcName,
+		pos:     Position{Line: lineOffset + 1, Col: 1},
+	}
+}
+
+// Tokenize splits the input into recognized tokens and returns them in order.
+// See New for the meaning of the arguments.
 func Tokenize(srcName string, lineOffset uint, input io.Reader) ([]Token, error) {
-	lineScanner := bufio.NewScanner(input)
-	lineScanner.Split(bufio.ScanLines)
+	l := New(srcName, lineOffset, input)
 
 	tokens := []Token{}
+	for {
+		tok, err := l.Next()
+		if errors.Is(err, io.EOF) {
+			return tokens, nil
+		}
+		if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, tok)
+	}
+}
 
-	var lineIdx uint = 0
+// Next returns the next token, io.EOF at the end of the input
+func (l *Lexer) Next() (Token, error) {
+	for {
+		r, start, err := l.read()
+		if err != nil {
+			return Token{}, err
+		}
 
-	finishAtom := func(b *strings.Builder, pos *int) {
-		if *pos >= 0 {
-			tokens = append(tokens, Token{
-				Typ:  Atom,
-				Line: lineIdx + lineOffset,
-				Pos:  uint(*pos),
-				Text: b.String(),
-			})
-			b.Reset()
-			*pos = -1
+		switch {
+		// ignore spaces
+		case unicode.IsSpace(r):
+			continue
+		case r == ';', r == '#' && start.Offset == 0 && l.peek('!'):
+			if err := l.skipLine(); err != nil {
+				return Token{}, err
+			}
+			continue
+		case r == '#' && l.peek('|'):
+			if err := l.skipBlockComment(start); err != nil {
+				return Token{}, err
+			}
+			continue
+		case r == '#' && l.peek(';'):
+			l.read()
+			return l.token(DatumComment, start, "#;"), nil
+		case r == '(':
+			return l.token(LParen, start, "("), nil
+		case r == ')':
+			return l.token(RParen, start, ")"), nil
+		case r == '\'':
+			return l.token(Quote, start, "'"), nil
+		case r == '"':
+			return l.string(start)
+		case isAtomRune(r):
+			return l.atom(r, start)
 		}
+
+		return Token{}, l.error(start, fmt.Sprintf("unexpected character %s", string(r)))
 	}
+}
 
-	for lineScanner.Scan() {
-		line := lineScanner.Text()
-		lineIdx++
+// read returns the next rune and its position
+func (l *Lexer) read() (rune, Position, error) {
+	r, size, err := l.rdr.ReadRune()
+	if err != nil {
+		return 0, l.pos, err
+	}
 
-		// ignore comments
-		if strings.HasPrefix(line, ";") {
-			continue
+	at := l.pos
+	l.pos.Offset += uint(size)
+	if r == '\n' {
+		l.pos.Line++
+		l.pos.Col = 1
+	} else {
+		l.pos.Col++
+	}
+
+	return r, at, nil
+}
+
+// peek reports whether the next rune is r without consuming it
+func (l *Lexer) peek(r rune) bool {
+	next, _, err := l.rdr.ReadRune()
+	if err != nil {
+		return false
+	}
+	l.rdr.UnreadRune()
+
+	return next == r
+}
+
+func (l *Lexer) token(typ TokenType, start Position, text string) Token {
+	return Token{
+		Typ:   typ,
+		Start: start,
+		End:   l.pos,
+		Text:  text,
+	}
+}
+
+func (l *Lexer) error(at Position, msg string) error {
+	return LexError{
+		SrcName: l.srcName,
+		Line:    at.Line,
+		Col:     at.Col,
+		Offset:  at.Offset,
+		Msg:     msg,
+	}
+}
+
+// incomplete returns an error about the input ending inside a token
+func (l *Lexer) incomplete(at Position, msg string) error {
+	err := l.error(at, msg).(LexError)
+	err.Incomplete = true
+
+	return err
+}
+
+// atom reads an atom starting with the already read rune first
+func (l *Lexer) atom(first rune, start Position) (Token, error) {
+	var b strings.Builder
+	b.WriteRune(first)
+
+	for {
+		r, _, err := l.rdr.ReadRune()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Token{}, err
+		}
+		l.rdr.UnreadRune()
+		if !isAtomRune(r) {
+			break
 		}
+		l.read()
+		b.WriteRune(r)
+	}
 
-		var atomBuf strings.Builder
-		pos := -1
-		for i, r := range line {
-			// if current rune is letter, start/append an the atom
-			if unicode.IsLetter(r) {
-				atomBuf.WriteRune(r)
-				if pos < 0 {
-					pos = i + 1
-				}
-				continue
-			}
+	text := b.String()
+	if strings.ContainsRune(text, ':') && !isQualified(text) {
+		return Token{}, l.error(start, fmt.Sprintf("invalid qualified symbol %s, expected package:name, package::name or :keyword", text))
+	}
 
-			// ignore spaces
-			if unicode.IsSpace(r) {
-				finishAtom(&atomBuf, &pos)
-				continue
-			}
+	return l.token(Atom, start, text), nil
+}
+
+// isQualified reports whether an atom is a symbol qualified with a package:
+// `package:name` refers to an exported name, `package::name` to any name.
+// Keywords like `:name` have no package.
+func isQualified(text string) bool {
+	pkg, name, _ := strings.Cut(text, ":")
+	if pkg != "" {
+		name = strings.TrimPrefix(name, ":")
+	}
+
+	return name != "" && !strings.ContainsRune(name, ':')
+}
+
+// string reads a string literal after the opening quote at start.
+// Strings may span lines.
+func (l *Lexer) string(start Position) (Token, error) {
+	var b strings.Builder
 
-			if r == '(' {
-				finishAtom(&atomBuf, &pos)
-				tokens = append(tokens, Token{
-					Typ:  LParen,
-					Line: lineIdx + lineOffset,
-					Pos:  uint(i + 1),
-					Text: "(",
-				})
-				continue
+	for {
+		r, _, err := l.read()
+		if errors.Is(err, io.EOF) {
+			return Token{}, l.incomplete(start, "string literal is not terminated")
+		}
+		if err != nil {
+			return Token{}, err
+		}
+
+		switch r {
+		case '"':
+			return l.token(String, start, b.String()), nil
+		case '\\':
+			r, at, err := l.read()
+			if errors.Is(err, io.EOF) {
+				return Token{}, l.incomplete(start, "string literal is not terminated")
 			}
-			if r == ')' {
-				finishAtom(&atomBuf, &pos)
-				tokens = append(tokens, Token{
-					Typ:  RParen,
-					Line: lineIdx + lineOffset,
-					Pos:  uint(i + 1),
-					Text: ")",
-				})
-				continue
+			if err != nil {
+				return Token{}, err
 			}
-			if r == '\'' {
-				finishAtom(&atomBuf, &pos)
-				tokens = append(tokens, Token{
-					Typ:  Quote,
-					Line: lineIdx + lineOffset,
-					Pos:  uint(i + 1),
-					Text: "'",
-				})
-				continue
+			switch r {
+			case 'n':
+				b.WriteRune('\n')
+			case 't':
+				b.WriteRune('\t')
+			case '\\', '"':
+				b.WriteRune(r)
+			default:
+				return Token{}, l.error(at, fmt.Sprintf("unknown escape sequence \\%s", string(r)))
 			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
 
-			// allow numbers and other punctuation symbols inside atoms
-			if unicode.IsDigit(r) || unicode.IsPunct(r) {
-				atomBuf.WriteRune(r)
-				if pos < 0 {
-					pos = i + 1
-				}
-				continue
-			}
+// skipLine skips a comment up to the end of the line
+func (l *Lexer) skipLine() error {
+	for {
+		r, _, err := l.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if r == '\n' {
+			return nil
+		}
+	}
+}
 
-			return tokens, Error{
-				srcName: srcName,
-				line:    lineIdx + lineOffset,
-				pos:     uint(i + 1),
-				msg:     fmt.Sprintf("unexpected character %s", string(r)),
-			}
+// skipBlockComment skips a block comment started by `#` at start,
+// block comments can be nested: #| a #| b |# c |#
+func (l *Lexer) skipBlockComment(start Position) error {
+	l.read() // |
+	depth := 1
+	var prev rune
+	for depth > 0 {
+		r, _, err := l.read()
+		if errors.Is(err, io.EOF) {
+			return l.incomplete(start, "block comment is not terminated")
 		}
-		// if reached end of the line and we're still reading an atom, finish it
-		if atomBuf.Len() != 0 {
-			tokens = append(tokens, Token{
-				Typ:  Atom,
-				Line: lineIdx + lineOffset,
-				Pos:  uint(pos),
-				Text: atomBuf.String(),
-			})
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case prev == '#' && r == '|':
+			depth++
+			r = 0 // #|# doesn't close the comment it opened
+		case prev == '|' && r == '#':
+			depth--
+			r = 0
 		}
+		prev = r
+	}
+
+	return nil
+}
+
+// isAtomRune reports whether r can be a part of an atom:
+// letters, digits and punctuation except delimiters
+func isAtomRune(r rune) bool {
+	if r == utf8.RuneError || strings.ContainsRune("()'\";", r) {
+		return false
 	}
 
-	return tokens, nil
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsPunct(r)
 }
@@ -1,6 +1,7 @@
 package lexer
 
 import (
+	"io"
 	"strings"
 	"testing"
 
@@ -11,10 +12,10 @@ import (
 func TestSingleLetterAtom(t *testing.T) {
 	input := "x"
 	expected := []Token{{
-		Typ:  Atom,
-		Line: 1,
-		Pos:  1,
-		Text: "x",
+		Typ:   Atom,
+		Start: Position{Line: 1, Col: 1, Offset: 0},
+		End:   Position{Line: 1, Col: 2, Offset: 1},
+		Text:  "x",
 	}}
 	tokens, err := Tokenize("test", 0, strings.NewReader(input))
 	require.NoError(t, err)
@@ -24,10 +25,10 @@ func TestSingleLetterAtom(t *testing.T) {
 func TestMultiLetterAtom(t *testing.T) {
 	input := "foo"
 	expected := []Token{{
-		Typ:  Atom,
-		Line: 1,
-		Pos:  1,
-		Text: "foo",
+		Typ:   Atom,
+		Start: Position{Line: 1, Col: 1, Offset: 0},
+		End:   Position{Line: 1, Col: 4, Offset: 3},
+		Text:  "foo",
 	}}
 	tokens, err := Tokenize("test", 0, strings.NewReader(input))
 	require.NoError(t, err)
@@ -38,16 +39,16 @@ func TestEmptyList(t *testing.T) {
 	input := "()"
 	expected := []Token{
 		{
-			Typ:  LParen,
-			Line: 1,
-			Pos:  1,
-			Text: "(",
+			Typ:   LParen,
+			Start: Position{Line: 1, Col: 1, Offset: 0},
+			End:   Position{Line: 1, Col: 2, Offset: 1},
+			Text:  "(",
 		},
 		{
-			Typ:  RParen,
-			Line: 1,
-			Pos:  2,
-			Text: ")",
+			Typ:   RParen,
+			Start: Position{Line: 1, Col: 2, Offset: 1},
+			End:   Position{Line: 1, Col: 3, Offset: 2},
+			Text:  ")",
 		},
 	}
 	tokens, err := Tokenize("test", 0, strings.NewReader(input))
@@ -59,22 +60,22 @@ func TestSingleAtomList(t *testing.T) {
 	input := "(foo)"
 	expected := []Token{
 		{
-			Typ:  LParen,
-			Line: 1,
-			Pos:  1,
-			Text: "(",
+			Typ:   LParen,
+			Start: Position{Line: 1, Col: 1, Offset: 0},
+			End:   Position{Line: 1, Col: 2, Offset: 1},
+			Text:  "(",
 		},
 		{
-			Typ:  Atom,
-			Line: 1,
-			Pos:  2,
-			Text: "foo",
+			Typ:   Atom,
+			Start: Position{Line: 1, Col: 2, Offset: 1},
+			End:   Position{Line: 1, Col: 5, Offset: 4},
+			Text:  "foo",
 		},
 		{
-			Typ:  RParen,
-			Line: 1,
-			Pos:  5,
-			Text: ")",
+			Typ:   RParen,
+			Start: Position{Line: 1, Col: 5, Offset: 4},
+			End:   Position{Line: 1, Col: 6, Offset: 5},
+			Text:  ")",
 		},
 	}
 	tokens, err := Tokenize("test", 0, strings.NewReader(input))
@@ -86,28 +87,28 @@ func TestMultiAtomList(t *testing.T) {
 	input := "(foo bar)"
 	expected := []Token{
 		{
-			Typ:  LParen,
-			Line: 1,
-			Pos:  1,
-			Text: "(",
+			Typ:   LParen,
+			Start: Position{Line: 1, Col: 1, Offset: 0},
+			End:   Position{Line: 1, Col: 2, Offset: 1},
+			Text:  "(",
 		},
 		{
-			Typ:  Atom,
-			Line: 1,
-			Pos:  2,
-			Text: "foo",
+			Typ:   Atom,
+			Start: Position{Line: 1, Col: 2, Offset: 1},
+			End:   Position{Line: 1, Col: 5, Offset: 4},
+			Text:  "foo",
 		},
 		{
-			Typ:  Atom,
-			Line: 1,
-			Pos:  6,
-			Text: "bar",
+			Typ:   Atom,
+			Start: Position{Line: 1, Col: 6, Offset: 5},
+			End:   Position{Line: 1, Col: 9, Offset: 8},
+			Text:  "bar",
 		},
 		{
-			Typ:  RParen,
-			Line: 1,
-			Pos:  9,
-			Text: ")",
+			Typ:   RParen,
+			Start: Position{Line: 1, Col: 9, Offset: 8},
+			End:   Position{Line: 1, Col: 10, Offset: 9},
+			Text:  ")",
 		},
 	}
 	tokens, err := Tokenize("test", 0, strings.NewReader(input))
@@ -119,28 +120,28 @@ func TestNestedEmptyLists(t *testing.T) {
 	input := "( ())"
 	expected := []Token{
 		{
-			Typ:  LParen,
-			Line: 1,
-			Pos:  1,
-			Text: "(",
+			Typ:   LParen,
+			Start: Position{Line: 1, Col: 1, Offset: 0},
+			End:   Position{Line: 1, Col: 2, Offset: 1},
+			Text:  "(",
 		},
 		{
-			Typ:  LParen,
-			Line: 1,
-			Pos:  3,
-			Text: "(",
+			Typ:   LParen,
+			Start: Position{Line: 1, Col: 3, Offset: 2},
+			End:   Position{Line: 1, Col: 4, Offset: 3},
+			Text:  "(",
 		},
 		{
-			Typ:  RParen,
-			Line: 1,
-			Pos:  4,
-			Text: ")",
+			Typ:   RParen,
+			Start: Position{Line: 1, Col: 4, Offset: 3},
+			End:   Position{Line: 1, Col: 5, Offset: 4},
+			Text:  ")",
 		},
 		{
-			Typ:  RParen,
-			Line: 1,
-			Pos:  5,
-			Text: ")",
+			Typ:   RParen,
+			Start: Position{Line: 1, Col: 5, Offset: 4},
+			End:   Position{Line: 1, Col: 6, Offset: 5},
+			Text:  ")",
 		},
 	}
 	tokens, err := Tokenize("test", 0, strings.NewReader(input))
@@ -152,46 +153,46 @@ func TestNestedNonEmptyLists(t *testing.T) {
 	input := "(foo (bar) baz)"
 	expected := []Token{
 		{
-			Typ:  LParen,
-			Line: 1,
-			Pos:  1,
-			Text: "(",
+			Typ:   LParen,
+			Start: Position{Line: 1, Col: 1, Offset: 0},
+			End:   Position{Line: 1, Col: 2, Offset: 1},
+			Text:  "(",
 		},
 		{
-			Typ:  Atom,
-			Line: 1,
-			Pos:  2,
-			Text: "foo",
+			Typ:   Atom,
+			Start: Position{Line: 1, Col: 2, Offset: 1},
+			End:   Position{Line: 1, Col: 5, Offset: 4},
+			Text:  "foo",
 		},
 		{
-			Typ:  LParen,
-			Line: 1,
-			Pos:  6,
-			Text: "(",
+			Typ:   LParen,
+			Start: Position{Line: 1, Col: 6, Offset: 5},
+			End:   Position{Line: 1, Col: 7, Offset: 6},
+			Text:  "(",
 		},
 		{
-			Typ:  Atom,
-			Line: 1,
-			Pos:  7,
-			Text: "bar",
+			Typ:   Atom,
+			Start: Position{Line: 1, Col: 7, Offset: 6},
+			End:   Position{Line: 1, Col: 10, Offset: 9},
+			Text:  "bar",
 		},
 		{
-			Typ:  RParen,
-			Line: 1,
-			Pos:  10,
-			Text: ")",
+			Typ:   RParen,
+			Start: Position{Line: 1, Col: 10, Offset: 9},
+			End:   Position{Line: 1, Col: 11, Offset: 10},
+			Text:  ")",
 		},
 		{
-			Typ:  Atom,
-			Line: 1,
-			Pos:  12,
-			Text: "baz",
+			Typ:   Atom,
+			Start: Position{Line: 1, Col: 12, Offset: 11},
+			End:   Position{Line: 1, Col: 15, Offset: 14},
+			Text:  "baz",
 		},
 		{
-			Typ:  RParen,
-			Line: 1,
-			Pos:  15,
-			Text: ")",
+			Typ:   RParen,
+			Start: Position{Line: 1, Col: 15, Offset: 14},
+			End:   Position{Line: 1, Col: 16, Offset: 15},
+			Text:  ")",
 		},
 	}
 	tokens, err := Tokenize("test", 0, strings.NewReader(input))
@@ -202,12 +203,189 @@ func TestNestedNonEmptyLists(t *testing.T) {
 func TestLineOffset(t *testing.T) {
 	input := "foo"
 	expected := []Token{{
-		Typ:  Atom,
-		Line: 6, // lineOffset=5, line 1 → 5+1=6
-		Pos:  1,
-		Text: "foo",
+		Typ:   Atom,
+		Start: Position{Line: 6, Col: 1, Offset: 0}, // lineOffset=5, line 1 → 5+1=6
+		End:   Position{Line: 6, Col: 4, Offset: 3},
+		Text:  "foo",
 	}}
 	tokens, err := Tokenize("test", 5, strings.NewReader(input))
 	require.NoError(t, err)
 	assert.Equal(t, expected, tokens)
 }
+
+func TestString(t *testing.T) {
+	input := `(f "a \"b\" ;c\n")`
+	expected := []Token{
+		{
+			Typ:   LParen,
+			Start: Position{Line: 1, Col: 1, Offset: 0},
+			End:   Position{Line: 1, Col: 2, Offset: 1},
+			Text:  "(",
+		},
+		{
+			Typ:   Atom,
+			Start: Position{Line: 1, Col: 2, Offset: 1},
+			End:   Position{Line: 1, Col: 3, Offset: 2},
+			Text:  "f",
+		},
+		{
+			Typ:   String,
+			Start: Position{Line: 1, Col: 4, Offset: 3},
+			End:   Position{Line: 1, Col: 18, Offset: 17},
+			Text:  "a \"b\" ;c\n",
+		},
+		{
+			Typ:   RParen,
+			Start: Position{Line: 1, Col: 18, Offset: 17},
+			End:   Position{Line: 1, Col: 19, Offset: 18},
+			Text:  ")",
+		},
+	}
+	tokens, err := Tokenize("test", 0, strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, expected, tokens)
+}
+
+func TestStringErrors(t *testing.T) {
+	_, err := Tokenize("test", 0, strings.NewReader(`"abc`))
+	require.ErrorContains(t, err, "test:1:1: lex error: string literal is not terminated")
+
+	_, err = Tokenize("test", 0, strings.NewReader(`"\q"`))
+	require.ErrorContains(t, err, `test:1:3: lex error: unknown escape sequence \q`)
+}
+
+func TestMultibyte(t *testing.T) {
+	input := "(λ \"ü\")\n 'é"
+	expected := []Token{
+		{
+			Typ:   LParen,
+			Start: Position{Line: 1, Col: 1, Offset: 0},
+			End:   Position{Line: 1, Col: 2, Offset: 1},
+			Text:  "(",
+		},
+		{
+			Typ:   Atom,
+			Start: Position{Line: 1, Col: 2, Offset: 1},
+			End:   Position{Line: 1, Col: 3, Offset: 3},
+			Text:  "λ",
+		},
+		{
+			Typ:   String,
+			Start: Position{Line: 1, Col: 4, Offset: 4},
+			End:   Position{Line: 1, Col: 7, Offset: 8},
+			Text:  "ü",
+		},
+		{
+			Typ:   RParen,
+			Start: Position{Line: 1, Col: 7, Offset: 8},
+			End:   Position{Line: 1, Col: 8, Offset: 9},
+			Text:  ")",
+		},
+		{
+			Typ:   Quote,
+			Start: Position{Line: 2, Col: 2, Offset: 11},
+			End:   Position{Line: 2, Col: 3, Offset: 12},
+			Text:  "'",
+		},
+		{
+			Typ:   Atom,
+			Start: Position{Line: 2, Col: 3, Offset: 12},
+			End:   Position{Line: 2, Col: 4, Offset: 14},
+			Text:  "é",
+		},
+	}
+	tokens, err := Tokenize("test", 0, strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, expected, tokens)
+}
+
+// texts returns the types and texts of tokens for compact comparison
+func texts(tokens []Token) []string {
+	result := []string{}
+	for _, tok := range tokens {
+		if tok.Typ == String {
+			result = append(result, `"`+tok.Text+`"`)
+			continue
+		}
+		result = append(result, tok.Text)
+	}
+	return result
+}
+
+func TestComments(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{
+		{"(car x) ; note", []string{"(", "car", "x", ")"}},
+		{"a;b\nc", []string{"a", "c"}},
+		{`"a;b" ; c`, []string{`"a;b"`}},
+		{"a #| b\n c |# d", []string{"a", "d"}},
+		{"a #| b #| c |# d |# e", []string{"a", "e"}},
+		{"a#b #|c|#", []string{"a#b"}},
+		{"#;(a b) c", []string{"#;", "(", "a", "b", ")", "c"}},
+		{"#!/usr/bin/env minimal-lisp\n(a)", []string{"(", "a", ")"}},
+		// only the first line can be a shebang
+		{"a\n#!b", []string{"a", "#!b"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			tokens, err := Tokenize("test", 0, strings.NewReader(tc.input))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, texts(tokens))
+		})
+	}
+
+	_, err := Tokenize("test", 0, strings.NewReader("a\n #| b #| c |#"))
+	require.ErrorContains(t, err, "test:2:2: lex error: block comment is not terminated")
+}
+
+func TestMultiLineTokens(t *testing.T) {
+	tokens, err := Tokenize("test", 0, strings.NewReader("(a \"b\nc\")"))
+	require.NoError(t, err)
+	require.Len(t, tokens, 4)
+	assert.Equal(t, Token{
+		Typ:   String,
+		Start: Position{Line: 1, Col: 4, Offset: 3},
+		End:   Position{Line: 2, Col: 3, Offset: 8},
+		Text:  "b\nc",
+	}, tokens[2])
+	assert.Equal(t, Position{Line: 2, Col: 3, Offset: 8}, tokens[3].Start)
+}
+
+func TestQualifiedSymbols(t *testing.T) {
+	tokens, err := Tokenize("test", 0, strings.NewReader("(list:second list::rest :keyword)"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"(", "list:second", "list::rest", ":keyword", ")"}, texts(tokens))
+
+	for _, input := range []string{"::a", "a:", "a:::b", "a:b:c", ":a:b"} {
+		_, err := Tokenize("test", 0, strings.NewReader(" "+input))
+		require.ErrorContains(t, err, "test:1:2: lex error: invalid qualified symbol "+input)
+	}
+}
+
+func TestLongLine(t *testing.T) {
+	long := strings.Repeat("a", 100_000)
+	tokens, err := Tokenize("test", 0, strings.NewReader("("+long+" b)"))
+	require.NoError(t, err)
+	require.Len(t, tokens, 4)
+	assert.Equal(t, long, tokens[1].Text)
+	assert.Equal(t, Position{Line: 1, Col: 100_003, Offset: 100_002}, tokens[2].Start)
+}
+
+func TestLexer(t *testing.T) {
+	l := New("test", 0, strings.NewReader("a 'b"))
+
+	tok, err := l.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "a", tok.Text)
+	tok, err = l.Next()
+	require.NoError(t, err)
+	assert.Equal(t, Quote, tok.Typ)
+	tok, err = l.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "b", tok.Text)
+	_, err = l.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
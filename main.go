@@ -1,59 +1,198 @@
+// Command minimal-lisp runs a LISP script or starts the REPL:
+//
+//	minimal-lisp [-i] [--no-prelude] [--no-init] [script.lisp [args...]]
+//	minimal-lisp [-i] [--no-prelude] [--no-init] -e '(expr)' [args...]
+//	minimal-lisp --watch script.lisp [args...]
+//
+// The arguments after the script or the expression are bound to `*argv*`
+// as a list of strings. Scripts may start with a shebang line.
+// With --watch the REPL starts after the script and the definitions
+// of the script are reloaded whenever it changes.
+// When the REPL starts, the init file $XDG_CONFIG_HOME/minilisp/init.lisp
+// (or ~/.minilisprc if it doesn't exist) is loaded before anything else.
+// It exits with status 1 if evaluation fails and 2 if the flags are wrong.
 package main
 
 import (
-	"embed"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"log"
 	"os"
-	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/reflechant/minimal-lisp/core"
-	"github.com/reflechant/minimal-lisp/parser"
+	"github.com/reflechant/minimal-lisp/diag"
+	"github.com/reflechant/minimal-lisp/interp"
 	"github.com/reflechant/minimal-lisp/repl"
 )
 
-//go:embed core.lisp
+// watchInterval is how often watched files are checked for changes
+const watchInterval = 500 * time.Millisecond
 
-var fs embed.FS
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command with the given arguments and returns the exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("minimal-lisp", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	expr := flags.String("e", "", "evaluate `expr` and print the result instead of running a script")
+	interactive := flags.Bool("i", false, "start the REPL after running the script or the expression")
+	noPrelude := flags.Bool("no-prelude", false, "don't load core.lisp")
+	watch := flags.Bool("watch", false, "reload the definitions of the script when it changes, implies -i")
+	noInit := flags.Bool("no-init", false, "don't load the init file before starting the REPL")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: minimal-lisp [flags] [script.lisp [args...]]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
 
-func Import(scope core.Scope, srcName string, src io.Reader) error {
-	exprs, err := parser.Parse(srcName, 0, src)
+	// reloads of watched files are reported from another goroutine
+	stdout, stderr = syncWriter(stdout), syncWriter(stderr)
+	it, err := interp.New(
+		interp.WithPrelude(!*noPrelude),
+		interp.WithPorts(stdin, stdout, stderr),
+	)
 	if err != nil {
-		return fmt.Errorf("%s: %w", srcName, err)
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	script, argv := "", flags.Args()
+	if *expr == "" && len(argv) > 0 {
+		script, argv = argv[0], argv[1:]
+	}
+	argvList := []core.SExpr{}
+	for _, arg := range argv {
+		argvList = append(argvList, core.NewString("", 0, 0, arg))
+	}
+	it.Define("*argv*", core.FromSlice(argvList))
+
+	*interactive = *interactive || *watch || (*expr == "" && script == "")
+	initPath := ""
+	if *interactive && !*noInit {
+		initPath = initFile()
 	}
-	for _, e := range exprs {
-		_, err := e.Eval(scope)
+	loadInit(it, initPath, stderr)
+
+	switch {
+	case *expr != "":
+		result, err := it.EvalReader("-e", strings.NewReader(*expr))
 		if err != nil {
-			return err
+			fmt.Fprint(stderr, it.FormatError(err, diag.ColorEnabled(stderr)))
+			return 1
+		}
+		if result != nil && !core.IsVoid(result) {
+			fmt.Fprintln(stdout, result)
+		}
+	case script != "" && *watch:
+		if err := it.WatchFile(script); err != nil {
+			fmt.Fprint(stderr, it.FormatError(err, diag.ColorEnabled(stderr)))
+			return 1
 		}
+	case script != "":
+		if err := it.LoadFile(script); err != nil {
+			fmt.Fprint(stderr, it.FormatError(err, diag.ColorEnabled(stderr)))
+			return 1
+		}
+	}
+	if !*interactive {
+		return 0
 	}
 
-	return nil
-}
+	// the interpreter is reset in place, so that the watched files are
+	// still reloaded into the scope of the REPL; *argv* is bound again by Reset
+	reset := func() (core.Scope, error) {
+		if err := it.Reset(); err != nil {
+			fmt.Fprint(stderr, it.FormatError(err, diag.ColorEnabled(stderr)))
+		}
+		loadInit(it, initPath, stderr)
+		return it.Scope(), nil
+	}
+	// files watched with --watch or watch-file are reloaded in the background
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go it.Watch(ctx, watchInterval, func(reloads []interp.Reload, err error) {
+		for _, r := range reloads {
+			fmt.Fprintf(stdout, "\nreloaded %s\n", r)
+		}
+		if err != nil {
+			fmt.Fprint(stderr, "\n"+it.FormatError(err, diag.ColorEnabled(stderr)))
+		}
+	})
 
-func main() {
-	file, err := fs.Open("core.lisp")
+	err = repl.REPL(it.Scope(), stdin, stdout, repl.WithReset(reset), repl.WithLock(it.Locker()))
 	if err != nil {
-		log.Fatalln(err)
+		fmt.Fprintln(stderr, err)
+		return 1
 	}
-	scope := core.BuiltinScope()
-	err = Import(scope, "core.lisp", file)
-	if err != nil {
-		log.Fatalln(err)
+
+	return 0
+}
+
+// initFile returns the path of the init file: init.lisp in the minilisp
+// directory of the user configuration directory ($XDG_CONFIG_HOME or ~/.config
+// on Linux) or ~/.minilisprc. It's empty if neither exists.
+func initFile() string {
+	candidates := []string{}
+	if dir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, filepath.Join(dir, "minilisp", "init.lisp"))
 	}
-	err = repl.REPL(scope, os.Stdin, os.Stdout)
-	if err != nil {
-		log.Fatalln(err)
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".minilisprc"))
+	}
+	for _, fpath := range candidates {
+		if _, err := os.Stat(fpath); err == nil {
+			return fpath
+		}
+	}
+
+	return ""
+}
+
+// loadInit loads the init file at fpath unless it's empty.
+// A broken init file is reported but doesn't prevent the REPL from starting,
+// none of its definitions are kept then.
+func loadInit(it *interp.Interpreter, fpath string, stderr io.Writer) {
+	if fpath == "" {
+		return
+	}
+	if err := it.LoadFileAtomic(fpath); err != nil {
+		fmt.Fprint(stderr, it.FormatError(err, diag.ColorEnabled(stderr)))
 	}
 }
 
-func ReadFile(fpath string) (*os.File, error) {
-	fpath = path.Clean(fpath)
-	file, err := os.Open(fpath)
-	if err != nil {
-		return nil, err
+// syncWriter returns w made safe for concurrent writes.
+// Files are safe already and are kept as they are, so that
+// terminals are still recognized.
+func syncWriter(w io.Writer) io.Writer {
+	if _, ok := w.(*os.File); ok {
+		return w
 	}
 
-	return file, nil
+	return &lockedWriter{w: w}
+}
+
+// lockedWriter serializes writes to w
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	return lw.w.Write(p)
 }
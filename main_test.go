@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runCmd(t *testing.T, stdin string, args ...string) (status int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut strings.Builder
+	status = run(args, strings.NewReader(stdin), &out, &errOut)
+
+	return status, out.String(), errOut.String()
+}
+
+// homeDir points the home and configuration directories to an empty temporary directory
+func homeDir(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	return home
+}
+
+func TestScript(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.lisp")
+	src := "#!/usr/bin/env minimal-lisp\n(print *argv*)\n(print (eval. '(car x) '((x (a b)))))\n"
+	require.NoError(t, os.WriteFile(script, []byte(src), 0o755))
+
+	status, stdout, stderr := runCmd(t, "", script, "one", "two")
+	assert.Equal(t, 0, status)
+	assert.Equal(t, "(\"one\" \"two\")\na\n", stdout)
+	assert.Empty(t, stderr)
+}
+
+func TestScriptError(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.lisp")
+	require.NoError(t, os.WriteFile(script, []byte("(print 'a)\n(car x)\n(print 'b)\n"), 0o644))
+
+	status, stdout, stderr := runCmd(t, "", script)
+	assert.Equal(t, 1, status)
+	assert.Equal(t, "a\n", stdout)
+	assert.Contains(t, stderr, script+":2:6: unbound symbol x")
+
+	status, _, stderr = runCmd(t, "", filepath.Join(t.TempDir(), "missing.lisp"))
+	assert.Equal(t, 1, status)
+	assert.Contains(t, stderr, "no such file or directory")
+}
+
+func TestExpr(t *testing.T) {
+	status, stdout, _ := runCmd(t, "", "-e", "(cons 'a *argv*)", "b", "c")
+	assert.Equal(t, 0, status)
+	assert.Equal(t, "(a \"b\" \"c\")\n", stdout)
+
+	status, _, _ = runCmd(t, "", "--no-prelude", "-e", "(eval. 'x '((x a)))")
+	assert.Equal(t, 1, status)
+}
+
+func TestInteractive(t *testing.T) {
+	homeDir(t)
+	status, stdout, _ := runCmd(t, "(car *argv*)\n", "-i", "-e", "(defun f (x) x)", "a")
+	assert.Equal(t, 0, status)
+	assert.Equal(t, "function f @ -e:1:8\n>>> \"a\"\n>>> ", stdout)
+}
+
+func TestResetKeepsArgv(t *testing.T) {
+	homeDir(t)
+	status, stdout, _ := runCmd(t, "*argv*\n:reset\n*argv*\n", "-i", "-e", "'x", "a", "b")
+	assert.Equal(t, 0, status)
+	assert.Equal(t, "x\n>>> (\"a\" \"b\")\n>>> >>> (\"a\" \"b\")\n>>> ", stdout)
+}
+
+func TestWatch(t *testing.T) {
+	homeDir(t)
+	script := filepath.Join(t.TempDir(), "script.lisp")
+	require.NoError(t, os.WriteFile(script, []byte("(defun f () 'a)\n"), 0o644))
+
+	status, stdout, _ := runCmd(t, "(f)\n", "--watch", script)
+	assert.Equal(t, 0, status)
+	assert.Equal(t, ">>> a\n>>> ", stdout)
+
+	// the watched script is loaded again after a reset
+	status, stdout, _ = runCmd(t, "(defun g () 'b)\n:reset\n(f)\n(g)\n", "--watch", script)
+	assert.Equal(t, 0, status)
+	assert.Contains(t, stdout, ">>> a\n>>> ")
+	assert.Contains(t, stdout, "unbound symbol g")
+}
+
+func TestInitFile(t *testing.T) {
+	home := homeDir(t)
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".minilisprc"), []byte("(defun f () 'rc)\n"), 0o644))
+
+	status, stdout, _ := runCmd(t, "(f)\n")
+	assert.Equal(t, 0, status)
+	assert.Equal(t, ">>> rc\n>>> ", stdout)
+
+	// the init file in the configuration directory comes first
+	xdgInit := filepath.Join(home, ".config", "minilisp", "init.lisp")
+	require.NoError(t, os.MkdirAll(filepath.Dir(xdgInit), 0o755))
+	require.NoError(t, os.WriteFile(xdgInit, []byte("(defun f () 'xdg)\n"), 0o644))
+	status, stdout, _ = runCmd(t, "(f)\n")
+	assert.Equal(t, 0, status)
+	assert.Equal(t, ">>> xdg\n>>> ", stdout)
+
+	// definitions of the script replace the ones of the init file
+	status, stdout, _ = runCmd(t, "(f)\n", "-i", "-e", "(defun f () 'e)")
+	assert.Equal(t, 0, status)
+	assert.Equal(t, "function f @ -e:1:8\n>>> e\n>>> ", stdout)
+
+	// scripts run without the REPL don't see it
+	status, _, stderr := runCmd(t, "", "-e", "(f)")
+	assert.Equal(t, 1, status)
+	assert.Contains(t, stderr, "unbound symbol f")
+
+	status, stdout, _ = runCmd(t, "(f)\n", "--no-init")
+	assert.Equal(t, 0, status)
+	assert.Contains(t, stdout, "unbound symbol f")
+}
+
+func TestBrokenInitFile(t *testing.T) {
+	home := homeDir(t)
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".minilisprc"), []byte("(defun f () 'rc)\n(car x)\n"), 0o644))
+
+	status, stdout, stderr := runCmd(t, "(f)\n'a\n")
+	assert.Equal(t, 0, status)
+	assert.Contains(t, stderr, ".minilisprc:2:6: unbound symbol x")
+	assert.Contains(t, stdout, ">>> a\n", "the REPL starts anyway")
+	assert.Contains(t, stdout, "unbound symbol f", "the definitions of a broken init file are dropped")
+}
+
+func TestBadFlag(t *testing.T) {
+	status, _, stderr := runCmd(t, "", "-x")
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr, "usage: minimal-lisp")
+}
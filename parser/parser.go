@@ -1,129 +1,283 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 
 	"github.com/reflechant/minimal-lisp/core"
 	"github.com/reflechant/minimal-lisp/lexer"
 )
 
-type Error struct {
-	srcName string
-	line    uint
-	pos     uint
-	msg     string
+// ParseError is returned when the tokens don't form a valid S-expression
+type ParseError struct {
+	Pos core.Position
+	// Token is the text of the offending token
+	Token string
+	Msg   string
+	// Incomplete is true if the input ended inside an expression,
+	// more input (e.g. the next REPL line) may complete it
+	Incomplete bool
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: parse error: %s", e.Pos.SrcName, e.Pos.Line, e.Pos.Col, e.Msg)
+}
+
+// Unwrap returns io.ErrUnexpectedEOF for incomplete input
+func (e ParseError) Unwrap() error {
+	if e.Incomplete {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
 }
 
-func (e Error) Error() string {
-	return fmt.Sprintf("%s:%d:%d: parse error: %s", e.srcName, e.line, e.pos, e.msg)
+// IsIncomplete reports whether err is caused by the input ending
+// in the middle of an expression, a string literal or a block comment
+func IsIncomplete(err error) bool {
+	return errors.Is(err, io.ErrUnexpectedEOF)
 }
 
 // Parse tokenizes and parses the input from srcName.
 // lineOffset is added to all line numbers, which lets the REPL report
 // cumulative line numbers across successive inputs.
 func Parse(srcName string, lineOffset uint, input io.Reader) ([]core.SExpr, error) {
-	tokens, err := lexer.Tokenize(srcName, lineOffset, input)
+	exprs := []core.SExpr{}
+	for expr, err := range NewReader(srcName, lineOffset, input).All() {
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+
+	return exprs, nil
+}
+
+// Reader reads S-expressions from the input one at a time,
+// only the tokens of the current expression are kept in memory.
+type Reader struct {
+	srcName string
+	lex     *lexer.Lexer
+	// peeked is the next token if it was looked at but not consumed
+	peeked *lexer.Token
+	// last is the last consumed token, parse errors at the end of input point to it
+	last lexer.Token
+	// err is the first error, the reader stops after it
+	err error
+}
+
+// NewReader returns a reader of the input, see Parse for the meaning of the arguments.
+func NewReader(srcName string, lineOffset uint, input io.Reader) *Reader {
+	return &Reader{
+		srcName: srcName,
+		lex:     lexer.New(srcName, lineOffset, input),
+	}
+}
+
+// Read returns the next expression, io.EOF at the end of the input.
+// After an error Read keeps returning it.
+func (r *Reader) Read() (core.SExpr, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	expr, err := r.read()
 	if err != nil {
+		r.err = err
 		return nil, err
 	}
 
-	exprs := []core.SExpr{}
+	return expr, nil
+}
+
+// All returns an iterator over the expressions of the input.
+// It stops after the first error.
+func (r *Reader) All() iter.Seq2[core.SExpr, error] {
+	return func(yield func(core.SExpr, error) bool) {
+		for {
+			expr, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(expr, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (r *Reader) read() (core.SExpr, error) {
+	if err := r.skipDatumComments(); err != nil {
+		return nil, err
+	}
+	tok, err := r.next()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.parse(tok)
+}
 
-	i := 0
-	for i < len(tokens) {
-		expr, next, err := parse(srcName, tokens, i)
+// peek returns the next token without consuming it
+func (r *Reader) peek() (lexer.Token, error) {
+	if r.peeked == nil {
+		tok, err := r.lex.Next()
 		if err != nil {
-			return nil, err
+			return tok, err
 		}
-		exprs = append(exprs, expr)
-		i = next
+		r.peeked = &tok
 	}
 
-	return exprs, nil
+	return *r.peeked, nil
 }
 
-func parse(srcName string, tokens []lexer.Token, start int) (core.SExpr, int, error) {
-	tok := tokens[start]
+// next consumes the next token
+func (r *Reader) next() (lexer.Token, error) {
+	tok, err := r.peek()
+	if err != nil {
+		return tok, err
+	}
+	r.peeked = nil
+	r.last = tok
 
+	return tok, nil
+}
+
+// parse parses the expression starting with the already consumed token tok
+func (r *Reader) parse(tok lexer.Token) (core.SExpr, error) {
 	switch tok.Typ {
 	case lexer.Atom:
-		return core.NewSymbol(srcName, tok.Line, tok.Pos, tok.Text), start + 1, nil
+		sym := core.NewSymbol(r.srcName, tok.Start.Line, tok.Start.Col, tok.Text)
+		return sym.WithSpan(r.span(tok.Start, tok.End)), nil
+	case lexer.String:
+		str := core.NewString(r.srcName, tok.Start.Line, tok.Start.Col, tok.Text)
+		return str.WithSpan(r.span(tok.Start, tok.End)), nil
 	case lexer.LParen:
-		return parseList(srcName, tokens, start) // we start at i so that it can set line and pos for the list
+		return r.parseList(tok)
 	case lexer.RParen:
-		return nil, start + 1, Error{
-			srcName: srcName,
-			line:    tok.Line,
-			pos:     tok.Pos,
-			msg:     fmt.Sprintf("unexpected token %v, can't close a list without first opening it", tok.Text),
+		return nil, ParseError{
+			Pos:   r.position(tok.Start),
+			Token: tok.Text,
+			Msg:   fmt.Sprintf("unexpected token %v, can't close a list without first opening it", tok.Text),
 		}
 	case lexer.Quote:
-		if start == len(tokens)-1 {
-			return nil, start + 1, Error{
-				srcName: srcName,
-				line:    tok.Line,
-				pos:     tok.Pos,
-				msg:     fmt.Sprintf("unexpected end of input: quote needs an argument"),
+		if err := r.skipDatumComments(); err != nil {
+			return nil, err
+		}
+		next, err := r.next()
+		if errors.Is(err, io.EOF) {
+			return nil, ParseError{
+				Pos:        r.position(tok.Start),
+				Token:      tok.Text,
+				Msg:        "unexpected end of input: quote needs an argument",
+				Incomplete: true,
 			}
 		}
-		quotedExpr, next, err := parse(srcName, tokens, start+1)
 		if err != nil {
-			return nil, next, err
+			return nil, err
 		}
+		quotedExpr, err := r.parse(next)
+		if err != nil {
+			return nil, err
+		}
+		quote := core.NewSymbol(r.srcName, tok.Start.Line, tok.Start.Col, "quote")
+		// 'x spans from the quote to the end of x
 		return core.NewList(
-			srcName,
-			tok.Line,
-			tok.Pos,
-			core.NewSymbol(srcName, tok.Line, tok.Pos, "quote"),
+			r.srcName,
+			tok.Start.Line,
+			tok.Start.Col,
+			quote.WithSpan(r.span(tok.Start, tok.End)),
 			quotedExpr,
-		), next, nil
+		).WithSpan(core.Span{Start: r.position(tok.Start), End: quotedExpr.Span().End}), nil
 	default:
-		return nil, start + 1, Error{
-			srcName: srcName,
-			line:    tok.Line,
-			pos:     tok.Pos,
-			msg:     fmt.Sprintf("unknown token %v", tok.Text),
+		return nil, ParseError{
+			Pos:   r.position(tok.Start),
+			Token: tok.Text,
+			Msg:   fmt.Sprintf("unknown token %v", tok.Text),
 		}
 	}
 }
 
-func parseList(srcName string, tokens []lexer.Token, start int) (core.SExpr, int, error) {
-	// start points to '(' which is guaranteed to exist by the caller
-	line, pos := tokens[start].Line, tokens[start].Pos
+// parseList parses the rest of the list opened by the already consumed token open
+func (r *Reader) parseList(open lexer.Token) (core.SExpr, error) {
+	line, pos := open.Start.Line, open.Start.Col
 
-	if start == len(tokens)-1 {
-		// if we're already at the end on input
-		return nil, start + 1, Error{
-			srcName: srcName,
-			line:    line,
-			pos:     pos,
-			msg:     fmt.Sprintf("list opened at %d:%d was not closed", line, pos),
+	items := []core.SExpr{}
+	for {
+		if err := r.skipDatumComments(); err != nil {
+			return nil, err
+		}
+		tok, err := r.next()
+		if errors.Is(err, io.EOF) {
+			return nil, ParseError{
+				Pos:        r.position(r.last.Start),
+				Token:      r.last.Text,
+				Msg:        fmt.Sprintf("list opened at %d:%d was not closed", line, pos),
+				Incomplete: true,
+			}
+		}
+		if err != nil {
+			return nil, err
 		}
-	}
 
-	items := []core.SExpr{}
-	i := start + 1
-	for i < len(tokens) {
-		tok := tokens[i]
 		if tok.Typ == lexer.RParen {
 			// end of the list, returning accumulated items
-			return core.NewList(srcName, line, pos, items...), i + 1, nil
+			return core.NewList(r.srcName, line, pos, items...).WithSpan(r.span(open.Start, tok.End)), nil
 		}
 
-		expr, next, err := parse(srcName, tokens, i)
+		expr, err := r.parse(tok)
 		if err != nil {
-			return nil, next, err
+			return nil, err
 		}
-
 		items = append(items, expr)
-		i = next
 	}
+}
 
-	return nil, i + 1, Error{
-		srcName: srcName,
-		line:    tokens[i-1].Line,
-		pos:     tokens[i-1].Pos,
-		msg:     fmt.Sprintf("list opened at %d:%d was not closed", line, pos),
+// skipDatumComments skips `#;` tokens and the expressions they comment out
+func (r *Reader) skipDatumComments() error {
+	for {
+		tok, err := r.peek()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if tok.Typ != lexer.DatumComment {
+			return nil
+		}
+		r.next()
+
+		// #; #; a b comments out both a and b
+		if err := r.skipDatumComments(); err != nil {
+			return err
+		}
+		next, err := r.peek()
+		eof := errors.Is(err, io.EOF)
+		if eof || (err == nil && next.Typ == lexer.RParen) {
+			return ParseError{
+				Pos:        r.position(tok.Start),
+				Token:      tok.Text,
+				Msg:        "datum comment needs an expression to comment out",
+				Incomplete: eof,
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := r.read(); err != nil {
+			return err
+		}
 	}
 }
+
+// position converts a lexer position to a source position
+func (r *Reader) position(p lexer.Position) core.Position {
+	return core.Position{SrcName: r.srcName, Line: p.Line, Col: p.Col, Offset: p.Offset}
+}
+
+// span returns the source span from start to end (exclusive)
+func (r *Reader) span(start, end lexer.Position) core.Span {
+	return core.Span{Start: r.position(start), End: r.position(end)}
+}
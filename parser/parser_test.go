@@ -1,11 +1,13 @@
 package parser
 
 import (
+	"io"
 	"slices"
 	"strings"
 	"testing"
 
 	"github.com/reflechant/minimal-lisp/core"
+	"github.com/reflechant/minimal-lisp/lexer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -15,7 +17,10 @@ func TestEmptyList(t *testing.T) {
 	exprs, err := Parse("test", 0, rdr)
 	require.NoError(t, err)
 	expected := []core.SExpr{
-		core.NewList("test", 1, 1),
+		core.NewList("test", 1, 1).WithSpan(core.Span{
+			Start: core.Position{SrcName: "test", Line: 1, Col: 1, Offset: 0},
+			End:   core.Position{SrcName: "test", Line: 1, Col: 3, Offset: 2},
+		}),
 	}
 	assert.Equal(t, expected, exprs)
 }
@@ -25,7 +30,10 @@ func TestOneAtom(t *testing.T) {
 	exprs, err := Parse("test", 0, rdr)
 	require.NoError(t, err)
 	expected := []core.SExpr{
-		core.NewSymbol("test", 1, 1, "foo"),
+		core.NewSymbol("test", 1, 1, "foo").WithSpan(core.Span{
+			Start: core.Position{SrcName: "test", Line: 1, Col: 1, Offset: 0},
+			End:   core.Position{SrcName: "test", Line: 1, Col: 4, Offset: 3},
+		}),
 	}
 	assert.Equal(t, expected, exprs)
 }
@@ -46,6 +54,19 @@ func TestNestedList(t *testing.T) {
 	}
 }
 
+func TestString(t *testing.T) {
+	rdr := strings.NewReader(`"foo bar"`)
+	exprs, err := Parse("test", 0, rdr)
+	require.NoError(t, err)
+	expected := []core.SExpr{
+		core.NewString("test", 1, 1, "foo bar").WithSpan(core.Span{
+			Start: core.Position{SrcName: "test", Line: 1, Col: 1, Offset: 0},
+			End:   core.Position{SrcName: "test", Line: 1, Col: 10, Offset: 9},
+		}),
+	}
+	assert.Equal(t, expected, exprs)
+}
+
 func TestMultiLine(t *testing.T) {
 	rdr := strings.NewReader(
 		`()
@@ -68,11 +89,43 @@ func TestLineOffset(t *testing.T) {
 	exprs, err := Parse("test", 5, rdr)
 	require.NoError(t, err)
 	expected := []core.SExpr{
-		core.NewSymbol("test", 6, 1, "foo"),
+		core.NewSymbol("test", 6, 1, "foo").WithSpan(core.Span{
+			Start: core.Position{SrcName: "test", Line: 6, Col: 1, Offset: 0},
+			End:   core.Position{SrcName: "test", Line: 6, Col: 4, Offset: 3},
+		}),
 	}
 	assert.Equal(t, expected, exprs)
 }
 
+func TestSpans(t *testing.T) {
+	rdr := strings.NewReader("(λ\n  '(ü \"ö\"))")
+	exprs, err := Parse("test", 0, rdr)
+	require.NoError(t, err)
+	require.Len(t, exprs, 1)
+
+	pos := func(line, col, offset uint) core.Position {
+		return core.Position{SrcName: "test", Line: line, Col: col, Offset: offset}
+	}
+
+	outer := exprs[0].(core.List)
+	assert.Equal(t, core.Span{Start: pos(1, 1, 0), End: pos(2, 12, 17)}, outer.Span())
+
+	lambda, _ := outer.Nth(0)
+	assert.Equal(t, core.Span{Start: pos(1, 2, 1), End: pos(1, 3, 3)}, lambda.Span())
+
+	quoted, _ := outer.Nth(1)
+	assert.Equal(t, core.Span{Start: pos(2, 3, 6), End: pos(2, 11, 16)}, quoted.Span())
+
+	quote, _ := quoted.(core.List).Nth(0)
+	assert.Equal(t, core.Span{Start: pos(2, 3, 6), End: pos(2, 4, 7)}, quote.Span())
+
+	list, _ := quoted.(core.List).Nth(1)
+	assert.Equal(t, core.Span{Start: pos(2, 4, 7), End: pos(2, 11, 16)}, list.Span())
+
+	str, _ := list.(core.List).Nth(1)
+	assert.Equal(t, core.Span{Start: pos(2, 7, 11), End: pos(2, 10, 15)}, str.Span())
+}
+
 func TestSyntaxErrors(t *testing.T) {
 	cases := []struct {
 		input          string
@@ -110,3 +163,117 @@ func TestSyntaxErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestErrorTypes(t *testing.T) {
+	_, err := Parse("test", 0, strings.NewReader("(a))"))
+	var parseErr ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, core.Position{SrcName: "test", Line: 1, Col: 4, Offset: 3}, parseErr.Pos)
+	assert.Equal(t, ")", parseErr.Token)
+
+	_, err = Parse("test", 0, strings.NewReader(`"abc`))
+	var lexErr lexer.LexError
+	require.ErrorAs(t, err, &lexErr)
+	assert.Equal(t, lexer.LexError{SrcName: "test", Line: 1, Col: 1, Msg: "string literal is not terminated", Incomplete: true}, lexErr)
+}
+
+func TestDatumComments(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{
+		{"#;(a b) c", []string{"c"}},
+		{"(a #;b c)", []string{"(a c)"}},
+		{"(a #;b)", []string{"(a)"}},
+		{"(a #; #; b c d)", []string{"(a d)"}},
+		{"'#;a b", []string{"(quote b)"}},
+		{"a ; (b\nc #| d) |#", []string{"a", "c"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			exprs, err := Parse("test", 0, strings.NewReader(tc.input))
+			require.NoError(t, err)
+			actual := []string{}
+			for _, e := range exprs {
+				actual = append(actual, e.String())
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+
+	_, err := Parse("test", 0, strings.NewReader("(a #;)"))
+	require.ErrorContains(t, err, "test:1:4: parse error: datum comment needs an expression to comment out")
+	_, err = Parse("test", 0, strings.NewReader("'#;a"))
+	require.ErrorContains(t, err, "quote needs an argument")
+}
+
+// failingReader fails the test if the reader reads past the expected input
+type failingReader struct {
+	t *testing.T
+}
+
+func (r failingReader) Read([]byte) (int, error) {
+	r.t.Fatal("read past the requested expression")
+	return 0, nil
+}
+
+func TestReader(t *testing.T) {
+	input := io.MultiReader(strings.NewReader("(a b) 'c"), failingReader{t})
+	rdr := NewReader("test", 0, input)
+
+	expr, err := rdr.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "(a b)", expr.String())
+}
+
+func TestReaderAll(t *testing.T) {
+	rdr := NewReader("test", 0, strings.NewReader("a (b #;c) ) d"))
+
+	exprs := []string{}
+	var lastErr error
+	for expr, err := range rdr.All() {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		exprs = append(exprs, expr.String())
+	}
+	assert.Equal(t, []string{"a", "(b)"}, exprs)
+	require.ErrorContains(t, lastErr, "test:1:11: parse error: unexpected token )")
+
+	// the reader stops at the first error
+	_, err := rdr.Read()
+	assert.Equal(t, lastErr, err)
+}
+
+func TestReaderEOF(t *testing.T) {
+	rdr := NewReader("test", 0, strings.NewReader(" ; nothing\n"))
+	_, err := rdr.Read()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestIncomplete(t *testing.T) {
+	cases := []struct {
+		input      string
+		incomplete bool
+	}{
+		{"(a", true},
+		{"(a (b)", true},
+		{"'", true},
+		{"#;", true},
+		{`"abc`, true},
+		{"#| abc", true},
+		{"(a))", false},
+		{"(a #;)", false},
+		{`"\q"`, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := Parse("test", 0, strings.NewReader(tc.input))
+			require.Error(t, err)
+			assert.Equal(t, tc.incomplete, IsIncomplete(err))
+		})
+	}
+}
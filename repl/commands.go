@@ -0,0 +1,243 @@
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/reflechant/minimal-lisp/core"
+	"github.com/reflechant/minimal-lisp/diag"
+	"github.com/reflechant/minimal-lisp/parser"
+)
+
+// Command is a REPL meta-command like `:load file`
+type Command struct {
+	// Name is typed after the colon
+	Name string
+	// Args describes the arguments in `:help`, e.g. "file"
+	Args string
+	// Help is a one line description
+	Help string
+	// Run executes the command, args is the rest of the line with spaces trimmed
+	Run func(s *Session, args string) error
+}
+
+func builtinCommands() map[string]Command {
+	commands := map[string]Command{}
+	for _, cmd := range []Command{
+		{Name: "help", Help: "list the commands", Run: help},
+		{Name: "load", Args: "file", Help: "evaluate a file", Run: load},
+		{Name: "reset", Help: "start over with a fresh scope", Run: resetScope},
+		{Name: "env", Help: "list the definitions made in the session", Run: env},
+		{Name: "doc", Args: "name", Help: "show the documentation or definition of a name", Run: doc},
+		{Name: "time", Args: "expr", Help: "evaluate an expression and show how long it took", Run: timeExpr},
+		{Name: "inspect", Args: "[expr]", Help: "walk the value of expr or the last result with car and cdr", Run: inspect},
+		{Name: "trace", Args: "fn ...", Help: "trace calls of functions, list traced functions without arguments", Run: traceFns},
+		{Name: "quit", Help: "leave the REPL", Run: quit},
+		{Name: "save", Args: "file", Help: "write the definitions made in the session to a file", Run: save},
+	} {
+		commands[cmd.Name] = cmd
+	}
+
+	return commands
+}
+
+// command runs a line like `:load file`
+func (s *Session) command(line string) error {
+	name, args, _ := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+	cmd, ok := s.cfg.commands[name]
+	if !ok {
+		return errors.New(fmt.Sprintf("unknown command :%s, type :help for the list of commands", name))
+	}
+
+	return cmd.Run(s, strings.TrimSpace(args))
+}
+
+// commandNames returns the names of the registered commands, sorted
+func (s *Session) commandNames() []string {
+	names := []string{}
+	for name := range s.cfg.commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+func help(s *Session, _ string) error {
+	usages := []string{}
+	width := 0
+	for _, name := range s.commandNames() {
+		usage := ":" + name
+		if args := s.cfg.commands[name].Args; args != "" {
+			usage += " " + args
+		}
+		usages = append(usages, usage)
+		width = max(width, len(usage))
+	}
+
+	var b strings.Builder
+	for i, name := range s.commandNames() {
+		fmt.Fprintf(&b, "  %-*s  %s\n", width, usages[i], s.cfg.commands[name].Help)
+	}
+	_, err := fmt.Fprint(s.out, b.String())
+	return err
+}
+
+func load(s *Session, args string) error {
+	if args == "" {
+		return errors.New(":load: expects a file name")
+	}
+	file, err := os.Open(args)
+	if err != nil {
+		return fmt.Errorf(":load: %w", err)
+	}
+	defer file.Close()
+
+	return s.Load(args, file)
+}
+
+func resetScope(s *Session, _ string) error {
+	// commands run holding the lock, the reset may need it
+	s.cfg.lock.Unlock()
+	scope, err := s.cfg.reset()
+	s.cfg.lock.Lock()
+	if err != nil {
+		return fmt.Errorf(":reset: %w", err)
+	}
+	s.setScope(scope)
+	s.defs = nil
+
+	return nil
+}
+
+func env(s *Session, _ string) error {
+	if len(s.defs) == 0 {
+		_, err := fmt.Fprintln(s.out, "no definitions")
+		return err
+	}
+
+	var b strings.Builder
+	for _, d := range s.defs {
+		val, ok := s.scope.SymbolValue(d.name)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "%s = %s\n", d.name, val)
+	}
+	_, err := fmt.Fprint(s.out, b.String())
+	return err
+}
+
+func doc(s *Session, args string) error {
+	if args == "" {
+		return errors.New(":doc: expects a name")
+	}
+
+	for _, d := range s.defs {
+		if d.name == args {
+			_, err := fmt.Fprintln(s.out, d.form)
+			return err
+		}
+	}
+
+	val, ok := s.scope.SymbolValue(args)
+	if !ok {
+		msg := fmt.Sprintf(":doc: %s is not bound", args)
+		if name, ok := diag.Suggest(args, s.scope.Names()); ok {
+			msg += fmt.Sprintf(", did you mean `%s`?", name)
+		}
+		return errors.New(msg)
+	}
+	if fn, ok := val.(core.Fn); ok && fn.Doc() != "" {
+		_, err := fmt.Fprintln(s.out, fn.Doc())
+		return err
+	}
+
+	_, err := fmt.Fprintln(s.out, val)
+	return err
+}
+
+// parseArgs parses the arguments of a command. They are kept as
+// the source <:cmd>, so errors in them show the arguments.
+func (s *Session) parseArgs(cmd, args string) ([]core.SExpr, error) {
+	srcName := "<:" + cmd + ">"
+	io.WriteString(s.keepSource(srcName), args)
+
+	return parser.Parse(srcName, 0, strings.NewReader(args))
+}
+
+func timeExpr(s *Session, args string) error {
+	exprs, err := s.parseArgs("time", args)
+	if err != nil {
+		return err
+	}
+	if len(exprs) != 1 {
+		return errors.New(fmt.Sprintf(":time: expects 1 expression, got %d", len(exprs)))
+	}
+
+	start := time.Now()
+	result, err := s.eval(exprs[0])
+	elapsed := time.Since(start)
+	if err != nil {
+		return err
+	}
+
+	if !core.IsVoid(result) {
+		s.remember(result)
+		if _, err := fmt.Fprintln(s.out, result); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(s.out, "time: %s\n", elapsed)
+	return err
+}
+
+func traceFns(s *Session, args string) error {
+	exprs, err := s.parseArgs("trace", "(trace "+args+")")
+	if err != nil {
+		return err
+	}
+	result, err := s.eval(exprs[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(s.out, result)
+	return err
+}
+
+func quit(s *Session, _ string) error {
+	s.Quit()
+	return nil
+}
+
+func save(s *Session, args string) error {
+	if args == "" {
+		return errors.New(":save: expects a file name")
+	}
+
+	var b strings.Builder
+	pkg := core.UserPackage
+	for _, d := range s.defs {
+		if d.pkg != pkg {
+			fmt.Fprintf(&b, "(in-package %s)\n", d.pkg)
+			pkg = d.pkg
+		}
+		b.WriteString(d.form.String())
+		b.WriteString("\n")
+	}
+	if pkg != core.UserPackage {
+		fmt.Fprintf(&b, "(in-package %s)\n", core.UserPackage)
+	}
+	if err := os.WriteFile(args, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf(":save: %w", err)
+	}
+
+	_, err := fmt.Fprintf(s.out, "saved %d definitions to %s\n", len(s.defs), args)
+	return err
+}
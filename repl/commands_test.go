@@ -0,0 +1,122 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reflechant/minimal-lisp/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func run(t *testing.T, input string, opts ...Option) string {
+	t.Helper()
+	var out strings.Builder
+	require.NoError(t, REPL(core.BuiltinScope(), strings.NewReader(input), &out, opts...))
+
+	return out.String()
+}
+
+func TestHelp(t *testing.T) {
+	output := run(t, ":help\n")
+	// descriptions are aligned to the longest usage
+	assert.Regexp(t, `\n  :load file +evaluate a file\n`, output)
+	assert.Regexp(t, `\n  :quit +leave the REPL\n`, output)
+}
+
+func TestUnknownCommand(t *testing.T) {
+	output := run(t, ":frobnicate\n")
+	assert.Contains(t, output, "unknown command :frobnicate, type :help for the list of commands")
+}
+
+func TestEnv(t *testing.T) {
+	output := run(t, ":env\n(defun f (x) x)\n(defun g (x) x)\n(defun f (x) (car x))\n:env\n")
+	assert.Contains(t, output, "no definitions\n")
+	// redefining moves the definition to the end
+	assert.Contains(t, output, "g = function g @ <repl>:3:8\nf = function f @ <repl>:4:8\n")
+}
+
+func TestDoc(t *testing.T) {
+	output := run(t, ":doc car\n(defun second (x) (car (cdr x)))\n:doc second\n:doc cdar\n")
+	assert.Contains(t, output, "(car x) returns")
+	assert.Contains(t, output, "(defun second (x) (car (cdr x)))\n")
+	assert.Contains(t, output, ":doc: cdar is not bound, did you mean `cdr`?")
+}
+
+func TestTime(t *testing.T) {
+	output := run(t, ":time (car '(a b))\n:time a b\n")
+	assert.Contains(t, output, "a\ntime: ")
+	assert.Contains(t, output, ":time: expects 1 expression, got 2")
+
+	// errors point into the command, not to the first line of the session
+	output = run(t, "(car '(a))\n:time (car (cdr x))\n")
+	assert.Contains(t, output, "<:time>:1:11: unbound symbol x\n  |\n1 | (car (cdr x))\n")
+}
+
+func TestQuit(t *testing.T) {
+	output := run(t, ":quit\n(car '(a))\n")
+	assert.Equal(t, ">>> ", output)
+}
+
+func TestSaveLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "session.lisp")
+	output := run(t, "(defun second (x) (car (cdr x)))\n(label first (lambda (x) (car x)))\n:save "+file+"\n")
+	assert.Contains(t, output, "saved 2 definitions to "+file)
+
+	saved, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "(defun second (x) (car (cdr x)))\n(label first (lambda (x) (car x)))\n", string(saved))
+
+	output = run(t, ":load "+file+"\n(first (second '((a) (b))))\n")
+	assert.Contains(t, output, ">>> b\n")
+}
+
+func TestSavePackages(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "session.lisp")
+	run(t, "(in-package list)\n(defun rest (x) (cdr x))\n(in-package user)\n(defun f (x) x)\n:save "+file+"\n")
+
+	saved, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "(in-package list)\n(defun rest (x) (cdr x))\n(in-package user)\n(defun f (x) x)\n", string(saved))
+
+	output := run(t, ":load "+file+"\n(list::rest '(a b))\n")
+	assert.Contains(t, output, ">>> (b)\n")
+}
+
+func TestLoadRollback(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "lib.lisp")
+	require.NoError(t, os.WriteFile(file, []byte("(defun f (x) x)\n(car)\n"), 0o644))
+
+	output := run(t, "(defun f (x) 'old)\n:load "+file+"\n(f 'new)\n:env\n")
+	assert.Contains(t, output, file+": loading failed, no definitions were kept:\n")
+	assert.Contains(t, output, ">>> old\n")
+	assert.Contains(t, output, "f = function f @ <repl>:1:8\n")
+}
+
+func TestReset(t *testing.T) {
+	output := run(t, "(defun f (x) x)\n:reset\n(f 'a)\n:env\n")
+	assert.Contains(t, output, "unbound symbol f")
+	assert.Contains(t, output, "no definitions\n")
+}
+
+func TestResetLoadsPrelude(t *testing.T) {
+	output := run(t, ":reset\n(null. '())\n")
+	assert.Contains(t, output, ">>> t\n", "the prelude is loaded again")
+}
+
+func TestWithCommand(t *testing.T) {
+	greet := Command{
+		Name: "greet",
+		Args: "name",
+		Help: "say hello",
+		Run: func(s *Session, args string) error {
+			_, err := s.Out().Write([]byte("hello " + args + "\n"))
+			return err
+		},
+	}
+	output := run(t, ":help\n:greet world\n", WithCommand(greet))
+	assert.Regexp(t, `:greet name +say hello\n`, output)
+	assert.Contains(t, output, "hello world\n")
+}
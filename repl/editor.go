@@ -0,0 +1,504 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// errInterrupted is returned by ReadLine when the user pressed Ctrl-C
+var errInterrupted = errors.New("interrupted")
+
+// lineReader reads lines of input showing a prompt,
+// the returned line ends with a newline unless the input ended
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// plainReader reads lines as they come, for input which is not a terminal
+type plainReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (r plainReader) ReadLine(prompt string) (string, error) {
+	if _, err := io.WriteString(r.out, prompt); err != nil {
+		return "", err
+	}
+
+	return r.in.ReadString('\n')
+}
+
+// keys which are not runes, decoded from escape sequences
+const (
+	keyUp rune = -(iota + 1)
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDelete
+	keyUnknown
+)
+
+// control keys
+const (
+	ctrlA     = 0x01
+	ctrlB     = 0x02
+	ctrlC     = 0x03
+	ctrlD     = 0x04
+	ctrlE     = 0x05
+	ctrlF     = 0x06
+	ctrlG     = 0x07
+	ctrlH     = 0x08
+	tab       = 0x09
+	ctrlK     = 0x0b
+	ctrlL     = 0x0c
+	enter     = 0x0d
+	ctrlN     = 0x0e
+	ctrlP     = 0x10
+	ctrlR     = 0x12
+	ctrlU     = 0x15
+	ctrlW     = 0x17
+	esc       = 0x1b
+	backspace = 0x7f
+)
+
+// ANSI escape sequences
+const (
+	clearToEnd  = "\x1b[K"
+	clearScreen = "\x1b[H\x1b[2J"
+	inverse     = "\x1b[7m"
+	reset       = "\x1b[0m"
+	bell        = "\a"
+)
+
+// editor is an interactive line editor for terminals in raw mode:
+// Emacs-style cursor movement and editing, history (Up/Down, Ctrl-R search),
+// tab completion and highlighting of the paren matching the one at the cursor.
+type editor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	history *history
+	// complete returns the candidates for completing a prefix
+	complete func(prefix string) []string
+	// raw switches the terminal to raw mode and returns a function restoring it,
+	// nil if the input is already raw (in tests)
+	raw func() (func() error, error)
+
+	// the line being edited
+	prompt string
+	buf    []rune
+	pos    int
+	// hist is the index of the shown history entry,
+	// len(history.entries) when editing a new line
+	hist int
+	// draft is the new line while browsing the history
+	draft []rune
+}
+
+func (e *editor) ReadLine(prompt string) (string, error) {
+	if e.raw != nil {
+		restore, err := e.raw()
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	e.prompt = prompt
+	e.buf = nil
+	e.pos = 0
+	e.hist = len(e.history.entries)
+	e.draft = nil
+	if err := e.render(); err != nil {
+		return "", err
+	}
+
+	for {
+		k, err := e.readKey()
+		if err != nil {
+			return "", err
+		}
+
+		switch k {
+		case enter, '\n':
+			return e.submit()
+		case ctrlC:
+			if _, err := io.WriteString(e.out, "^C\r\n"); err != nil {
+				return "", err
+			}
+			return "", errInterrupted
+		case ctrlD:
+			if len(e.buf) == 0 {
+				if _, err := io.WriteString(e.out, "\r\n"); err != nil {
+					return "", err
+				}
+				return "", io.EOF
+			}
+			e.delete()
+		case keyDelete:
+			e.delete()
+		case backspace, ctrlH:
+			if e.pos > 0 {
+				e.pos--
+				e.delete()
+			}
+		case keyLeft, ctrlB:
+			e.pos = max(0, e.pos-1)
+		case keyRight, ctrlF:
+			e.pos = min(len(e.buf), e.pos+1)
+		case keyHome, ctrlA:
+			e.pos = 0
+		case keyEnd, ctrlE:
+			e.pos = len(e.buf)
+		case ctrlK:
+			e.buf = e.buf[:e.pos]
+		case ctrlU:
+			e.buf = slices.Delete(e.buf, 0, e.pos)
+			e.pos = 0
+		case ctrlW:
+			start := e.wordStart()
+			e.buf = slices.Delete(e.buf, start, e.pos)
+			e.pos = start
+		case keyUp, ctrlP:
+			e.browse(e.hist - 1)
+		case keyDown, ctrlN:
+			e.browse(e.hist + 1)
+		case ctrlL:
+			if _, err := io.WriteString(e.out, clearScreen); err != nil {
+				return "", err
+			}
+		case tab:
+			if err := e.completeWord(); err != nil {
+				return "", err
+			}
+		case ctrlR:
+			submit, err := e.search()
+			if err != nil {
+				return "", err
+			}
+			if submit {
+				return e.submit()
+			}
+		default:
+			if k >= ' ' {
+				e.insert(k)
+			}
+		}
+
+		if err := e.render(); err != nil {
+			return "", err
+		}
+	}
+}
+
+// submit finishes editing and returns the line
+func (e *editor) submit() (string, error) {
+	// show the line without highlighting
+	e.pos = len(e.buf)
+	if err := e.render(); err != nil {
+		return "", err
+	}
+	if _, err := io.WriteString(e.out, "\r\n"); err != nil {
+		return "", err
+	}
+
+	line := string(e.buf)
+	e.history.add(line)
+
+	return line + "\n", nil
+}
+
+// readKey reads a rune or decodes an escape sequence of a special key
+func (e *editor) readKey() (rune, error) {
+	r, _, err := e.in.ReadRune()
+	if err != nil || r != esc {
+		return r, err
+	}
+
+	// a lone Esc is not followed by anything
+	if e.in.Buffered() == 0 {
+		return esc, nil
+	}
+	next, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if next != '[' && next != 'O' {
+		return keyUnknown, nil
+	}
+
+	code, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch code {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	}
+
+	// sequences like ESC [ 3 ~
+	if code < '0' || code > '9' {
+		return keyUnknown, nil
+	}
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r == '~' {
+			break
+		}
+		if r < '0' || r > '9' {
+			return keyUnknown, nil
+		}
+	}
+	switch code {
+	case '1', '7':
+		return keyHome, nil
+	case '4', '8':
+		return keyEnd, nil
+	case '3':
+		return keyDelete, nil
+	}
+
+	return keyUnknown, nil
+}
+
+func (e *editor) insert(runes ...rune) {
+	e.buf = slices.Insert(e.buf, e.pos, runes...)
+	e.pos += len(runes)
+}
+
+// delete deletes the rune at the cursor
+func (e *editor) delete() {
+	if e.pos < len(e.buf) {
+		e.buf = slices.Delete(e.buf, e.pos, e.pos+1)
+	}
+}
+
+// wordStart returns the position where the symbol before the cursor starts
+func (e *editor) wordStart() int {
+	i := e.pos
+	// skip the delimiters right before the cursor
+	for i > 0 && isDelimiter(e.buf[i-1]) {
+		i--
+	}
+	for i > 0 && !isDelimiter(e.buf[i-1]) {
+		i--
+	}
+
+	return i
+}
+
+func isDelimiter(r rune) bool {
+	return r == ' ' || r == '\t' || strings.ContainsRune("()'\";", r)
+}
+
+// browse shows the i-th history entry, the new line after the last one
+func (e *editor) browse(i int) {
+	entries := e.history.entries
+	if i < 0 || i > len(entries) || i == e.hist {
+		return
+	}
+	if e.hist == len(entries) {
+		e.draft = e.buf
+	}
+	e.hist = i
+
+	if i == len(entries) {
+		e.buf = e.draft
+	} else {
+		e.buf = []rune(entries[i])
+	}
+	e.pos = len(e.buf)
+}
+
+// completeWord completes the symbol before the cursor with the bound names.
+// If there are several candidates their common prefix is inserted
+// or, if there is nothing to insert, the candidates are listed.
+func (e *editor) completeWord() error {
+	if e.complete == nil {
+		return nil
+	}
+	start := e.pos
+	for start > 0 && !isDelimiter(e.buf[start-1]) {
+		start--
+	}
+	prefix := string(e.buf[start:e.pos])
+
+	candidates := e.complete(prefix)
+	switch len(candidates) {
+	case 0:
+		_, err := io.WriteString(e.out, bell)
+		return err
+	case 1:
+		e.insert([]rune(strings.TrimPrefix(candidates[0], prefix))...)
+		if e.pos == len(e.buf) {
+			e.insert(' ')
+		}
+		return nil
+	}
+
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, common) {
+			_, size := utf8.DecodeLastRuneInString(common)
+			common = common[:len(common)-size]
+		}
+	}
+	if len(common) > len(prefix) {
+		e.insert([]rune(strings.TrimPrefix(common, prefix))...)
+		return nil
+	}
+
+	_, err := fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	return err
+}
+
+// search is the reverse incremental search of the history (Ctrl-R).
+// Typing narrows the search, Ctrl-R finds an older match,
+// Enter submits the match, Ctrl-G, Esc and Ctrl-C cancel the search,
+// other keys leave the match in the line for editing.
+func (e *editor) search() (submit bool, err error) {
+	var query []rune
+	found := -1
+	before := len(e.history.entries)
+
+	for {
+		match := ""
+		if found >= 0 {
+			match = e.history.entries[found]
+		}
+		status := "(reverse-i-search)"
+		if found < 0 && len(query) > 0 {
+			status = "(failed reverse-i-search)"
+		}
+		_, err := fmt.Fprintf(e.out, "\r%s`%s': %s%s", status, string(query), match, clearToEnd)
+		if err != nil {
+			return false, err
+		}
+
+		k, err := e.readKey()
+		if err != nil {
+			return false, err
+		}
+		switch k {
+		case ctrlR:
+			if found >= 0 {
+				before = found
+			}
+		case backspace, ctrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			before = len(e.history.entries)
+		case ctrlG, ctrlC, esc:
+			return false, nil
+		case enter, '\n':
+			if found >= 0 {
+				e.buf = []rune(match)
+			}
+			return true, nil
+		default:
+			if k < ' ' {
+				if found >= 0 {
+					e.buf = []rune(match)
+					e.pos = len(e.buf)
+				}
+				return false, nil
+			}
+			// a longer query may still match the shown entry
+			if found >= 0 {
+				before = found + 1
+			}
+			query = append(query, k)
+		}
+
+		if len(query) == 0 {
+			found = -1
+			continue
+		}
+		if i := e.history.search(string(query), before); i >= 0 || k != ctrlR {
+			found = i
+		}
+	}
+}
+
+// render redraws the line and puts the cursor in place
+func (e *editor) render() error {
+	var b strings.Builder
+	b.WriteString("\r")
+	b.WriteString(e.prompt)
+
+	match := matchingParen(e.buf, e.pos)
+	for i, r := range e.buf {
+		if i == match {
+			b.WriteString(inverse)
+			b.WriteRune(r)
+			b.WriteString(reset)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(clearToEnd)
+
+	b.WriteString("\r")
+	if n := utf8.RuneCountInString(e.prompt) + e.pos; n > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", n)
+	}
+
+	_, err := io.WriteString(e.out, b.String())
+	return err
+}
+
+// matchingParen returns the index of the paren matching
+// the closing paren before the cursor or the opening paren at the cursor,
+// -1 if there is none
+func matchingParen(buf []rune, pos int) int {
+	switch {
+	case pos > 0 && buf[pos-1] == ')':
+		depth := 0
+		for i := pos - 1; i >= 0; i-- {
+			switch buf[i] {
+			case ')':
+				depth++
+			case '(':
+				depth--
+				if depth == 0 {
+					return i
+				}
+			}
+		}
+	case pos < len(buf) && buf[pos] == '(':
+		depth := 0
+		for i := pos; i < len(buf); i++ {
+			switch buf[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return i
+				}
+			}
+		}
+	}
+
+	return -1
+}
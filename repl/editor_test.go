@@ -0,0 +1,160 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestEditor returns an editor reading the given keys
+func newTestEditor(keys string, h *history, out io.Writer) *editor {
+	return &editor{
+		in:      bufio.NewReader(strings.NewReader(keys)),
+		out:     out,
+		history: h,
+		complete: func(prefix string) []string {
+			names := []string{}
+			for _, name := range []string{"caar", "cadr", "car", "cdr", "cons"} {
+				if strings.HasPrefix(name, prefix) {
+					names = append(names, name)
+				}
+			}
+			return names
+		},
+	}
+}
+
+func TestEditorKeys(t *testing.T) {
+	cases := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"plain", "(car x)\r", "(car x)"},
+		{"left and insert", "(car )\x1b[D\x1b[Dx\r", "(carx )"},
+		{"home and end", "car x\x01(\x05)\r", "(car x)"},
+		{"backspace and delete", "(cars\x7f x)\x01\x1b[3~\r", "car x)"},
+		{"kill to end", "(car x)\x01\x06\x06\x0b\r", "(c"},
+		{"kill to start", "(car x)\x02\x15\r", ")"},
+		{"delete word", "(cons x y\x17z)\r", "(cons x z)"},
+		{"complete single", "(cons '(a) co\t\r", "(cons '(a) cons "},
+		{"complete common prefix", "(ca\t\r", "(ca"},
+		{"complete common prefix", "(cd\tx)\r", "(cdr x)"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newTestEditor(tc.keys, &history{}, io.Discard)
+			line, err := e.ReadLine(">>> ")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected+"\n", line)
+		})
+	}
+}
+
+func TestEditorControl(t *testing.T) {
+	e := newTestEditor("(car\x03", &history{}, io.Discard)
+	_, err := e.ReadLine(">>> ")
+	assert.ErrorIs(t, err, errInterrupted)
+
+	e = newTestEditor("\x04", &history{}, io.Discard)
+	_, err = e.ReadLine(">>> ")
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestEditorCompletionList(t *testing.T) {
+	var out strings.Builder
+	e := newTestEditor("(c\t\r", &history{}, &out)
+	_, err := e.ReadLine(">>> ")
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "\r\ncaar  cadr  car  cdr  cons\r\n")
+}
+
+func TestEditorHistory(t *testing.T) {
+	h := &history{entries: []string{"(car x)", "(cdr y)"}}
+
+	cases := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"previous", "\x1b[A\r", "(cdr y)"},
+		{"older", "\x1b[A\x1b[A\r", "(car x)"},
+		{"back to the draft", "(co\x1b[A\x1b[B\r", "(co"},
+		{"edit an entry", "\x10\x10\x7f\x7fz)\r", "(car z)"},
+		{"search", "\x12car\r", "(car x)"},
+		{"search older", "\x12(c\x12\r", "(car x)"},
+		{"search and edit", "\x12cd\x05 z\r", "(cdr y) z"},
+		{"cancel search", "a\x12car\x07\r", "a"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			hist := &history{entries: append([]string{}, h.entries...)}
+			e := newTestEditor(tc.keys, hist, io.Discard)
+			line, err := e.ReadLine(">>> ")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected+"\n", line)
+		})
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h := loadHistory(path)
+	h.add("(car x)")
+	h.add("(car x)")
+	h.add("  ")
+	h.add("(cdr x)")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "(car x)\n(cdr x)\n", string(data))
+	assert.Equal(t, []string{"(car x)", "(cdr x)"}, loadHistory(path).entries)
+
+	// lines are appended until the file has twice maxHistory lines,
+	// then it's compacted to the last maxHistory entries
+	fileLines := func() int {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return strings.Count(string(data), "\n")
+	}
+	for i := range maxHistory + 5 {
+		h.add(fmt.Sprintf("(f %d)", i))
+	}
+	assert.Equal(t, maxHistory+7, fileLines())
+	assert.Equal(t, h.entries, loadHistory(path).entries)
+	for i := range maxHistory {
+		h.add(fmt.Sprintf("(g %d)", i))
+	}
+	assert.Equal(t, maxHistory+6, fileLines())
+	assert.Equal(t, h.entries, loadHistory(path).entries)
+
+	// long lines are read whole
+	long := "'" + strings.Repeat("a", 100*1024)
+	h.add(long)
+	entries := loadHistory(path).entries
+	assert.Equal(t, long, entries[len(entries)-1])
+}
+
+func TestMatchingParen(t *testing.T) {
+	line := []rune("(a (b c) d)")
+	assert.Equal(t, 3, matchingParen(line, 8))
+	assert.Equal(t, 0, matchingParen(line, 11))
+	assert.Equal(t, 10, matchingParen(line, 0))
+	assert.Equal(t, -1, matchingParen(line, 5))
+
+	var out strings.Builder
+	e := newTestEditor("(a (b)\r", &history{}, &out)
+	_, err := e.ReadLine(">>> ")
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "(a "+inverse+"("+reset+"b)")
+}
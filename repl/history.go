@@ -0,0 +1,139 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxHistory is the number of history entries kept
+const maxHistory = 1000
+
+// history is the list of entered lines, the oldest first.
+// Lines are appended to a file so they survive restarts,
+// the file is compacted to the last maxHistory lines once it has twice as many.
+type history struct {
+	entries []string
+	// path is the history file, empty to keep history in memory only
+	path string
+	// fileLines is the number of lines in the history file
+	fileLines int
+}
+
+// defaultHistoryFile returns ~/.minilisp_history
+// or an empty string if there is no home directory
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".minilisp_history")
+}
+
+// loadHistory reads the history file at path, a missing file is an empty history
+func loadHistory(path string) *history {
+	h := &history{path: path}
+	if path == "" {
+		return h
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer file.Close()
+
+	// lines are read whole however long they are
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadString('\n')
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			h.entries = append(h.entries, line)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return h
+		}
+	}
+	h.fileLines = len(h.entries)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+
+	return h
+}
+
+// add appends a line to the history unless it's blank or repeats the last one.
+// Failing to write the history file is not worth interrupting the user.
+func (h *history) add(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == line {
+		return
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[1:]
+	}
+
+	if h.path == "" {
+		return
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	_, err = file.WriteString(line + "\n")
+	file.Close()
+	if err != nil {
+		return
+	}
+	h.fileLines++
+	if h.fileLines > 2*maxHistory {
+		h.rewrite()
+	}
+}
+
+// rewrite replaces the history file with the entries kept in memory,
+// so that it doesn't grow without bounds
+func (h *history) rewrite() {
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, entry := range h.entries {
+		w.WriteString(entry + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	if err := os.Rename(tmp.Name(), h.path); err == nil {
+		h.fileLines = len(h.entries)
+	}
+}
+
+// search returns the index of the newest entry before index `before`
+// containing query, -1 if there is none
+func (h *history) search(query string, before int) int {
+	for i := min(before, len(h.entries)) - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,218 @@
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/reflechant/minimal-lisp/core"
+)
+
+const (
+	inspectPrompt = "inspect> "
+	// defaultInspectDepth is how deep nested lists are printed by the inspector
+	defaultInspectDepth = 3
+	// maxInspectItems is how many elements of a list are printed by the inspector
+	maxInspectItems = 20
+)
+
+const inspectHelp = `  a, car     go to the car
+  d, cdr     go to the cdr
+  <n>        go to the n-th element, counting from 0
+  u, up      go back
+  ?, help    show this list
+  depth <n>  print nested lists n levels deep
+  q, quit    leave the inspector
+`
+
+// inspector walks a cons structure, see `:inspect`
+type inspector struct {
+	// path leads from the inspected value to the current one
+	path  []inspected
+	depth int
+}
+
+// inspected is a value reached in the inspector with an expression computing it
+type inspected struct {
+	expr string
+	val  core.SExpr
+}
+
+func inspect(s *Session, args string) error {
+	root := inspected{expr: "*"}
+	if args == "" {
+		val, ok := s.scope.SymbolValue("*")
+		if !ok {
+			return errors.New(":inspect: there is no result yet, give an expression")
+		}
+		root.val = val
+	} else {
+		exprs, err := s.parseArgs("inspect", args)
+		if err != nil {
+			return err
+		}
+		if len(exprs) != 1 {
+			return errors.New(fmt.Sprintf(":inspect: expects 1 expression, got %d", len(exprs)))
+		}
+		val, err := s.eval(exprs[0])
+		if err != nil {
+			return err
+		}
+		root = inspected{expr: exprs[0].String(), val: val}
+	}
+
+	in := inspector{path: []inspected{root}, depth: defaultInspectDepth}
+	if err := in.show(s.out); err != nil {
+		return err
+	}
+	for {
+		// commands run holding the lock, it's not held while waiting for input,
+		// walking the value doesn't need it
+		s.cfg.lock.Unlock()
+		line, err := s.lines.ReadLine(inspectPrompt)
+		s.cfg.lock.Lock()
+		if errors.Is(err, errInterrupted) || errors.Is(err, io.EOF) && line == "" {
+			return nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "?" || line == "help" {
+			if _, err := io.WriteString(s.out, inspectHelp); err != nil {
+				return err
+			}
+			continue
+		}
+
+		done, err := in.command(line)
+		if done {
+			return nil
+		}
+		if err != nil {
+			if _, err := fmt.Fprintln(s.out, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := in.show(s.out); err != nil {
+			return err
+		}
+	}
+}
+
+// command runs an inspector command and reports whether the inspector should quit
+func (in *inspector) command(line string) (done bool, err error) {
+	cmd, arg, _ := strings.Cut(line, " ")
+	cur := in.path[len(in.path)-1]
+
+	switch cmd {
+	case "":
+		return false, nil
+	case "q", "quit":
+		return true, nil
+	case "u", "up":
+		if len(in.path) == 1 {
+			return false, errors.New("already at the inspected value")
+		}
+		in.path = in.path[:len(in.path)-1]
+	case "a", "car":
+		l, err := nonEmptyList(cur.val)
+		if err != nil {
+			return false, err
+		}
+		in.path = append(in.path, inspected{expr: "(car " + cur.expr + ")", val: l.First()})
+	case "d", "cdr":
+		l, err := nonEmptyList(cur.val)
+		if err != nil {
+			return false, err
+		}
+		in.path = append(in.path, inspected{expr: "(cdr " + cur.expr + ")", val: l.Second()})
+	case "depth":
+		depth, err := strconv.Atoi(strings.TrimSpace(arg))
+		if err != nil || depth < 1 {
+			return false, errors.New("depth expects a positive number")
+		}
+		in.depth = depth
+	default:
+		i, err := strconv.Atoi(cmd)
+		if err != nil {
+			return false, errors.New(fmt.Sprintf("unknown inspector command %s, type ? for the list of commands", cmd))
+		}
+		l, err := nonEmptyList(cur.val)
+		if err != nil {
+			return false, err
+		}
+		val, ok := l.Nth(i)
+		if !ok {
+			return false, errors.New(fmt.Sprintf("the list has %d elements", l.Len()))
+		}
+		expr := cur.expr
+		for range i {
+			expr = "(cdr " + expr + ")"
+		}
+		in.path = append(in.path, inspected{expr: "(car " + expr + ")", val: val})
+	}
+
+	return false, nil
+}
+
+// show prints the current value and its elements if it's a list
+func (in *inspector) show(out io.Writer) error {
+	cur := in.path[len(in.path)-1]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s = %s\n", cur.expr, abbreviate(cur.val, in.depth))
+	if l, ok := cur.val.(core.List); ok {
+		i := 0
+		for item := range l.Items() {
+			if i == maxInspectItems {
+				fmt.Fprintf(&b, "  ... %d more\n", l.Len()-i)
+				break
+			}
+			fmt.Fprintf(&b, "  %d: %s\n", i, abbreviate(item, in.depth-1))
+			i++
+		}
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func nonEmptyList(e core.SExpr) (core.List, error) {
+	l, ok := e.(core.List)
+	if !ok {
+		return core.List{}, errors.New(fmt.Sprintf("%s is not a list", e))
+	}
+	if l.IsEmpty() {
+		return core.List{}, errors.New("the list is empty")
+	}
+
+	return l, nil
+}
+
+// abbreviate prints e showing nested lists up to depth levels as (...)
+// and up to maxInspectItems elements of every list
+func abbreviate(e core.SExpr, depth int) string {
+	l, ok := e.(core.List)
+	if !ok || l.IsEmpty() {
+		return e.String()
+	}
+	if depth <= 0 {
+		return "(...)"
+	}
+
+	items := []string{}
+	for item := range l.Items() {
+		if len(items) == maxInspectItems {
+			items = append(items, "...")
+			break
+		}
+		items = append(items, abbreviate(item, depth-1))
+	}
+
+	return "(" + strings.Join(items, " ") + ")"
+}
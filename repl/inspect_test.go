@@ -0,0 +1,80 @@
+package repl
+
+import (
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/reflechant/minimal-lisp/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	input := "'(a (b (c (d))) e)\n:inspect\n1\nd\na\nu\nu\ndepth 1\nq\n(car '(x))\n"
+	expected := ">>> (a (b (c (d))) e)\n" +
+		">>> * = (a (b (c (...))) e)\n" +
+		"  0: a\n" +
+		"  1: (b (c (...)))\n" +
+		"  2: e\n" +
+		"inspect> (car (cdr *)) = (b (c (d)))\n" +
+		"  0: b\n" +
+		"  1: (c (d))\n" +
+		"inspect> (cdr (car (cdr *))) = ((c (d)))\n" +
+		"  0: (c (d))\n" +
+		"inspect> (car (cdr (car (cdr *)))) = (c (d))\n" +
+		"  0: c\n" +
+		"  1: (d)\n" +
+		"inspect> (cdr (car (cdr *))) = ((c (d)))\n" +
+		"  0: (c (d))\n" +
+		"inspect> (car (cdr *)) = (b (c (d)))\n" +
+		"  0: b\n" +
+		"  1: (c (d))\n" +
+		"inspect> (car (cdr *)) = (b (...))\n" +
+		"  0: b\n" +
+		"  1: (...)\n" +
+		"inspect> >>> x\n" +
+		">>> "
+	assert.Equal(t, expected, run(t, input))
+}
+
+func TestInspectErrors(t *testing.T) {
+	output := run(t, ":inspect\n:inspect (car '(a))\nd\n7\nu\nx\nq\n")
+	assert.Contains(t, output, ":inspect: there is no result yet, give an expression")
+	assert.Contains(t, output, "inspect> a is not a list\n")
+	assert.Contains(t, output, "inspect> already at the inspected value\n")
+	assert.Contains(t, output, "inspect> unknown inspector command x, type ? for the list of commands\n")
+
+	output = run(t, "(car '(a))\n:inspect (cdr x)\n")
+	assert.Contains(t, output, "<:inspect>:1:6: unbound symbol x\n  |\n1 | (cdr x)\n")
+}
+
+// lineByLine returns its lines one per read, noting whether lock was free
+// when every line was asked for
+type lineByLine struct {
+	lines []string
+	lock  *sync.Mutex
+	free  []bool
+}
+
+func (r *lineByLine) Read(p []byte) (int, error) {
+	if len(r.lines) == 0 {
+		return 0, io.EOF
+	}
+	free := r.lock.TryLock()
+	if free {
+		r.lock.Unlock()
+	}
+	r.free = append(r.free, free)
+	n := copy(p, r.lines[0])
+	r.lines = r.lines[1:]
+
+	return n, nil
+}
+
+func TestInspectReleasesLock(t *testing.T) {
+	var lock sync.Mutex
+	in := &lineByLine{lines: []string{":inspect '(a b)\n", "a\n", "q\n"}, lock: &lock}
+	require.NoError(t, REPL(core.BuiltinScope(), in, io.Discard, WithLock(&lock)))
+	assert.Equal(t, []bool{true, true, true}, in.free, "the lock isn't held while waiting for input")
+}
@@ -2,63 +2,394 @@ package repl
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strings"
+	"sync"
 
 	"github.com/reflechant/minimal-lisp/core"
+	"github.com/reflechant/minimal-lisp/diag"
+	"github.com/reflechant/minimal-lisp/interp"
 	"github.com/reflechant/minimal-lisp/parser"
 )
 
-const prompt = ">>> "
+const (
+	prompt = ">>> "
+	// packagePrompt follows the name of the current package unless it's user
+	packagePrompt = "> "
+	// continuationPrompt is shown while the typed expression is not complete
+	continuationPrompt = "... "
+	// sourceLines is the number of the last lines of every source kept for error messages
+	sourceLines = 1000
+)
 
-func REPL(scope core.Scope, in io.Reader, out io.Writer) error {
-	// print the REPL prompt
-	_, err := out.Write([]byte(prompt))
-	if err != nil {
-		return err
+// config holds the REPL options
+type config struct {
+	historyFile string
+	reset       func() (core.Scope, error)
+	commands    map[string]Command
+	lock        sync.Locker
+}
+
+// Option configures a REPL
+type Option func(c *config)
+
+// WithHistoryFile sets the file the entered lines are saved to
+// (~/.minilisp_history by default), an empty path disables saving.
+// History is only kept when the REPL runs in a terminal.
+func WithHistoryFile(path string) Option {
+	return func(c *config) {
+		c.historyFile = path
+	}
+}
+
+// WithReset sets how `:reset` creates a fresh scope,
+// by default it is the scope of a new interp.Interpreter with the default prelude.
+// reset is called without holding the lock set with WithLock,
+// so it may call the methods of an interpreter, e.g. Reset.
+func WithReset(reset func() (core.Scope, error)) Option {
+	return func(c *config) {
+		c.reset = reset
+	}
+}
+
+// WithCommand registers a meta-command, it replaces a builtin command with the same name.
+func WithCommand(cmd Command) Option {
+	return func(c *config) {
+		c.commands[cmd.Name] = cmd
+	}
+}
+
+// WithLock makes the REPL hold lock while evaluating input and running commands,
+// e.g. the lock of an interpreter reloading watched files in the background.
+func WithLock(lock sync.Locker) Option {
+	return func(c *config) {
+		c.lock = lock
+	}
+}
+
+// Session is the state of a running REPL available to commands
+type Session struct {
+	scope core.Scope
+	in    *bufio.Reader
+	out   io.Writer
+	cfg   config
+	lines lineReader
+
+	// sources keeps the last lines typed and loaded, errors show the offending line from them
+	sources  diag.Windows
+	renderer diag.Renderer
+	// defs are the definitions made in the session, the oldest first
+	defs []definition
+	quit bool
+}
+
+// definition is a top-level defun or label form
+type definition struct {
+	// name is qualified with the package the definition was made in
+	name string
+	form core.List
+	pkg  string
+}
+
+// REPL reads expressions line by line from in, evaluates them in scope
+// and writes the results to out. An expression may span several lines,
+// they are accumulated until it's complete. Evaluated code uses in and out
+// as its current input and output ports.
+//
+// Lines starting with a colon are meta-commands, see `:help`.
+// The prompt shows the current package unless it's user.
+// The last three results are bound to `*`, `**` and `***`
+// and the message of the last error to `*e`.
+//
+// When in and out are a terminal the lines are read with a line editor
+// supporting history, reverse search (Ctrl-R) and completion of bound names (Tab).
+func REPL(scope core.Scope, in io.Reader, out io.Writer, opts ...Option) error {
+	cfg := config{
+		historyFile: defaultHistoryFile(),
+		reset: func() (core.Scope, error) {
+			it, err := interp.New()
+			if err != nil {
+				return core.Scope{}, err
+			}
+			return it.Scope(), nil
+		},
+		commands: builtinCommands(),
+		lock:     noLock{},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	s := &Session{
+		in:      bufio.NewReader(in),
+		out:     out,
+		cfg:     cfg,
+		sources: diag.Windows{},
+	}
+	s.setScope(scope)
+	s.renderer = diag.Renderer{
+		Color:  diag.ColorEnabled(out),
+		Source: s.sources.Line,
+		Names:  func() []string { return s.scope.Names() },
 	}
+	s.lines = newLineReader(s, in)
+	typed := s.keepSource("<repl>")
 
 	var lineCount uint // tracks cumulative lines so errors say e.g. <repl>:3:5
 
-	scanner := bufio.NewScanner(in)
-	for scanner.Scan() {
-		line := scanner.Text()
-		rdr := strings.NewReader(line)
-		exprs, err := parser.Parse("<repl>", lineCount, rdr)
+	var input strings.Builder // lines of the incomplete expression typed so far
+	var inputLine uint        // number of lines before the input
+
+	for !s.quit {
+		p := prompt
+		cfg.lock.Lock()
+		if pkg := s.scope.Package(); pkg != core.UserPackage {
+			p = pkg + packagePrompt
+		}
+		cfg.lock.Unlock()
+		if input.Len() > 0 {
+			p = continuationPrompt
+		}
+		line, err := s.lines.ReadLine(p)
+		if errors.Is(err, errInterrupted) {
+			// Ctrl-C drops the incomplete expression
+			input.Reset()
+			continue
+		}
+		eof := errors.Is(err, io.EOF)
+		if eof && line == "" && input.Len() == 0 {
+			return nil
+		}
+		if err != nil && !eof {
+			return err
+		}
+
+		if input.Len() == 0 {
+			inputLine = lineCount
+		}
+		io.WriteString(typed, line)
 		lineCount++
-		if err != nil {
-			_, err := out.Write(fmt.Appendf(nil, "%v\n", err))
+
+		if input.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			cfg.lock.Lock()
+			err := s.command(strings.TrimSpace(line))
+			cfg.lock.Unlock()
 			if err != nil {
+				if err := s.printError(err); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		input.WriteString(line)
+		exprs, err := parser.Parse("<repl>", inputLine, strings.NewReader(input.String()))
+		if parser.IsIncomplete(err) && !eof {
+			continue
+		}
+		input.Reset()
+		if err != nil {
+			if err := s.printError(err); err != nil {
 				return err
 			}
 		}
 
 		for _, e := range exprs {
-			result, err := e.Eval(scope)
+			cfg.lock.Lock()
+			result, err := s.eval(e)
+			if err == nil && !core.IsVoid(result) {
+				s.remember(result)
+			}
+			cfg.lock.Unlock()
 			if err != nil {
-				_, err := out.Write(fmt.Appendf(nil, "%v\n", err))
-				if err != nil {
+				if err := s.printError(err); err != nil {
 					return err
 				}
 				continue
 			}
 
-			if result != nil {
-				_, err := out.Write(fmt.Appendf(nil, "%v\n", result.String()))
-				if err != nil {
+			if !core.IsVoid(result) {
+				if _, err := fmt.Fprintln(out, result); err != nil {
 					return err
 				}
-				continue
 			}
 		}
+	}
 
-		// print the REPL prompt
-		_, err = out.Write([]byte(prompt))
-		if err != nil {
-			return err
+	return nil
+}
+
+// Scope returns the scope expressions are evaluated in
+func (s *Session) Scope() core.Scope {
+	return s.scope
+}
+
+// Out returns the output of the REPL
+func (s *Session) Out() io.Writer {
+	return s.out
+}
+
+// Quit stops the REPL after the current command
+func (s *Session) Quit() {
+	s.quit = true
+}
+
+// Load evaluates everything read from src without printing the results.
+// srcName is used in error messages. The definitions are kept only if
+// all the expressions succeed, otherwise a *core.LoadError lists the failures
+// and the scope is unchanged, so reloading a broken file is harmless.
+func (s *Session) Load(srcName string, src io.Reader) error {
+	defs := []definition{}
+	forms := parser.NewReader(srcName, 0, io.TeeReader(src, s.keepSource(srcName))).All()
+	err := s.scope.LoadAtomic(srcName, forms, func(e, result core.SExpr) {
+		if d, ok := s.definition(e, result); ok {
+			defs = append(defs, d)
 		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, d := range defs {
+		s.define(d)
 	}
 
 	return nil
 }
+
+// keepSource returns a new window keeping the last lines of the named source
+func (s *Session) keepSource(srcName string) *diag.Window {
+	w := diag.NewWindow(sourceLines)
+	s.sources[srcName] = w
+
+	return w
+}
+
+// setScope makes the session evaluate in scope reading and writing the REPL ports
+func (s *Session) setScope(scope core.Scope) {
+	s.scope = scope.WithPorts(s.in, s.out, s.out)
+}
+
+// eval evaluates a top-level expression and remembers it if it's a definition
+func (s *Session) eval(e core.SExpr) (core.SExpr, error) {
+	result, err := e.Eval(s.scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if d, ok := s.definition(e, result); ok {
+		s.define(d)
+	}
+
+	return result, nil
+}
+
+// definition returns the definition made by a top-level expression if it's one
+func (s *Session) definition(e, result core.SExpr) (definition, bool) {
+	name, ok := core.DefinedName(e)
+	if !ok {
+		return definition{}, false
+	}
+	if fn, ok := result.(core.Fn); ok {
+		name = fn.Name()
+	}
+
+	return definition{name: name, form: e.(core.List), pkg: s.scope.Package()}, true
+}
+
+// define remembers a definition replacing the previous one of the same name
+func (s *Session) define(d definition) {
+	s.defs = deleteDefinition(s.defs, d.name)
+	s.defs = append(s.defs, d)
+}
+
+func deleteDefinition(defs []definition, name string) []definition {
+	result := defs[:0]
+	for _, d := range defs {
+		if d.name != name {
+			result = append(result, d)
+		}
+	}
+
+	return result
+}
+
+// remember binds the result to `*` shifting the previous ones to `**` and `***`
+func (s *Session) remember(result core.SExpr) {
+	if v, ok := s.scope.SymbolValue("**"); ok {
+		s.scope.Bind("***", v)
+	}
+	if v, ok := s.scope.SymbolValue("*"); ok {
+		s.scope.Bind("**", v)
+	}
+	s.scope.Bind("*", result)
+}
+
+// printError shows err and binds its message to `*e`
+func (s *Session) printError(err error) error {
+	s.cfg.lock.Lock()
+	s.scope.Bind("*e", core.NewString("", 0, 0, err.Error()))
+	msg := s.renderer.Render(err)
+	s.cfg.lock.Unlock()
+
+	_, err = io.WriteString(s.out, msg)
+	return err
+}
+
+// noLock is the lock of a REPL which is the only one evaluating in its scope
+type noLock struct{}
+
+func (noLock) Lock()   {}
+func (noLock) Unlock() {}
+
+// newLineReader returns a line editor if the REPL runs in a terminal
+// and a plain line reader otherwise
+func newLineReader(s *Session, in io.Reader) lineReader {
+	inFile, ok := in.(*os.File)
+	if !ok || !isTerminal(inFile.Fd()) {
+		return plainReader{in: s.in, out: s.out}
+	}
+	outFile, ok := s.out.(*os.File)
+	if !ok || !isTerminal(outFile.Fd()) {
+		return plainReader{in: s.in, out: s.out}
+	}
+
+	return &editor{
+		in:      s.in,
+		out:     s.out,
+		history: loadHistory(s.cfg.historyFile),
+		complete: func(prefix string) []string {
+			return s.completions(prefix)
+		},
+		raw: func() (func() error, error) {
+			return makeRaw(inFile.Fd())
+		},
+	}
+}
+
+// completions returns the bound names or, for a prefix starting
+// with a colon, the commands starting with prefix
+func (s *Session) completions(prefix string) []string {
+	names := []string{}
+	if strings.HasPrefix(prefix, ":") {
+		for _, name := range s.commandNames() {
+			if strings.HasPrefix(":"+name, prefix) {
+				names = append(names, ":"+name)
+			}
+		}
+		return names
+	}
+
+	s.cfg.lock.Lock()
+	defer s.cfg.lock.Unlock()
+	for _, name := range s.scope.Names() {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
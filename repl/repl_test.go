@@ -0,0 +1,53 @@
+package repl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/reflechant/minimal-lisp/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiLine(t *testing.T) {
+	in := strings.NewReader("(defun second (x)\n\n  (car (cdr x)))\n(second\n  '(a b c))\n")
+	var out strings.Builder
+	require.NoError(t, REPL(core.BuiltinScope(), in, &out))
+
+	expected := ">>> ... ... function second @ <repl>:1:8\n" +
+		">>> ... b\n" +
+		">>> "
+	assert.Equal(t, expected, out.String())
+}
+
+func TestMultiLineErrors(t *testing.T) {
+	in := strings.NewReader("(car\n  x)\n(car '(a)))\n(cons 'a")
+	var out strings.Builder
+	require.NoError(t, REPL(core.BuiltinScope(), in, &out))
+
+	output := out.String()
+	// line numbers are counted across inputs
+	assert.Contains(t, output, "<repl>:2:3: unbound symbol x")
+	assert.Contains(t, output, "<repl>:3:11: parse error: unexpected token )")
+	// the input ended before the expression was complete
+	assert.Contains(t, output, "<repl>:4:8: parse error: list opened at 4:1 was not closed")
+}
+
+func TestResultHistory(t *testing.T) {
+	output := run(t, "(car '(a))\n(car '(b))\n(car '(c))\n(cons * (cons ** (cons *** '())))\nx\n*e\n")
+	assert.Contains(t, output, ">>> (c b a)\n")
+	assert.Contains(t, output, `>>> "<repl>:5:1: unbound symbol x"`)
+}
+
+func TestPackagePrompt(t *testing.T) {
+	output := run(t, "(in-package list)\n(defun second (x) (car (cdr x)))\n(in-package user)\n")
+	assert.Equal(t, ">>> list\nlist> function list:second @ <repl>:2:8\nlist> user\n>>> ", output)
+}
+
+func TestSourceWindow(t *testing.T) {
+	// only the last lines typed are kept, the error still shows its line
+	input := strings.Repeat("'a\n", sourceLines+10) + "(car x)\n"
+	output := run(t, input)
+	assert.Contains(t, output, fmt.Sprintf("%d | (car x)", sourceLines+11))
+}
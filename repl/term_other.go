@@ -0,0 +1,15 @@
+//go:build !linux
+
+package repl
+
+import "errors"
+
+// isTerminal reports whether fd is a terminal,
+// line editing is only supported on Linux so far
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func makeRaw(fd uintptr) (func() error, error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}